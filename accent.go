@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"embed"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+//go:embed wordlists/*.txt
+var wordlistData embed.FS
+
+// Loads the word list used for diacritic restoration: the built-in list for
+// lang plus words-<lang>.txt from the data directory, if present.
+func loadWordList(lang string) []string {
+	var words []string
+	read := func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				words = append(words, line)
+			}
+		}
+	}
+	if f, err := wordlistData.Open("wordlists/" + lang + ".txt"); err == nil {
+		read(f)
+		f.Close()
+	}
+	if path, err := dataPath("words-" + lang + ".txt"); err == nil {
+		if f, err := os.Open(path); err == nil {
+			read(f)
+			f.Close()
+		}
+	}
+	return words
+}
+
+// Returns the words in the list that match word when accents are ignored,
+// excluding word itself, e.g. "nino" gives "niño".
+func restoreDiacritics(word string, words []string) []string {
+	folded := foldAccents(word)
+	seen := map[string]bool{strings.ToLower(word): true}
+	var out []string
+	for _, w := range words {
+		lw := strings.ToLower(w)
+		if foldAccents(lw) == folded && !seen[lw] {
+			seen[lw] = true
+			out = append(out, lw)
+		}
+	}
+	return out
+}
+
+// Dead-key style shortcuts: a letter followed by a marker becomes the
+// accented letter, and doubled ? and ! become the inverted marks.
+var deadKeys = map[string]string{
+	"a'": "á", "e'": "é", "i'": "í", "o'": "ó", "u'": "ú",
+	"A'": "Á", "E'": "É", "I'": "Í", "O'": "Ó", "U'": "Ú",
+	"n~": "ñ", "N~": "Ñ", "u\"": "ü", "U\"": "Ü",
+	"??": "¿", "!!": "¡",
+}
+
+// Replaces a dead-key pair just before the cursor with its accented letter.
+func applyDeadKeys(ti *textinput.Model) {
+	value := []rune(ti.Value())
+	pos := ti.Position()
+	if pos < 2 || pos > len(value) {
+		return
+	}
+	repl, ok := deadKeys[string(value[pos-2:pos])]
+	if !ok {
+		return
+	}
+	out := append(append(append([]rune{}, value[:pos-2]...), []rune(repl)...), value[pos:]...)
+	ti.SetValue(string(out))
+	ti.SetCursor(pos - 2 + len([]rune(repl)))
+}
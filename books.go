@@ -0,0 +1,251 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// book is an ingested EPUB or plain-text file split into sentences. The
+// sentences are stored under books/ in the data directory; the word index is
+// rebuilt when the books are loaded.
+type book struct {
+	Title     string   `json:"title"`
+	Source    string   `json:"source"`
+	Sentences []string `json:"sentences"`
+
+	index map[string][]int // lowercase word -> sentence indexes
+}
+
+// bookExample is a sentence from a book containing a searched word.
+type bookExample struct {
+	Book     string
+	Sentence string
+	Match    string // the word as written in the sentence
+}
+
+func ingestBook(file string) (*book, error) {
+	var text, title string
+	var err error
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".epub":
+		title, text, err = readEPUB(file)
+	default:
+		var data []byte
+		data, err = os.ReadFile(file)
+		text = string(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if title == "" {
+		title = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	b := &book{Title: title, Source: file, Sentences: splitSentences(text)}
+	if len(b.Sentences) == 0 {
+		return nil, fmt.Errorf("no text found in %s", file)
+	}
+
+	dir, err := booksDir()
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, bookFileName(file)), data, 0o644); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Names a book's stored file after its source path, so books with the same
+// file name in different directories are kept apart while re-ingesting the
+// same file replaces it.
+func bookFileName(file string) string {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	sum := sha256.Sum256([]byte(file))
+	base := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	return fmt.Sprintf("%s-%s.json", base, hex.EncodeToString(sum[:4]))
+}
+
+func booksDir() (string, error) {
+	dir, err := dataPath("books")
+	if err != nil {
+		return "", err
+	}
+	return dir, os.MkdirAll(dir, 0o755)
+}
+
+// Loads every ingested book and builds its word index.
+func loadBooks() ([]*book, error) {
+	dir, err := booksDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	var books []*book
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		var b book
+		if err := json.Unmarshal(data, &b); err != nil {
+			return nil, fmt.Errorf("%s: %w", filepath.Base(f), err)
+		}
+		b.buildIndex()
+		books = append(books, &b)
+	}
+	return books, nil
+}
+
+func (b *book) buildIndex() {
+	b.index = map[string][]int{}
+	for i, s := range b.Sentences {
+		for _, w := range tokenize(s) {
+			if ids := b.index[w]; len(ids) == 0 || ids[len(ids)-1] != i {
+				b.index[w] = append(ids, i)
+			}
+		}
+	}
+}
+
+// Returns up to limit sentences across books that contain word, preferring
+// shorter sentences since they make better cards.
+func findBookExamples(books []*book, word string, limit int) []bookExample {
+	word = strings.ToLower(word)
+	var found []bookExample
+	for _, b := range books {
+		for _, i := range b.index[word] {
+			s := b.Sentences[i]
+			found = append(found, bookExample{Book: b.Title, Sentence: s, Match: matchedForm(s, word)})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return len(found[i].Sentence) < len(found[j].Sentence)
+	})
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+// Returns word as it is capitalized in sentence.
+func matchedForm(sentence, word string) string {
+	for _, f := range strings.FieldsFunc(sentence, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		if strings.ToLower(f) == word {
+			return f
+		}
+	}
+	return word
+}
+
+// EPUB reading
+
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+type epubPackage struct {
+	Title    string `xml:"metadata>title"`
+	Manifest []struct {
+		ID   string `xml:"id,attr"`
+		Href string `xml:"href,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// Returns the title and the text of an EPUB's chapters in reading order.
+func readEPUB(file string) (string, string, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return "", "", err
+	}
+	defer zr.Close()
+
+	var container epubContainer
+	if err := readZipXML(&zr.Reader, "META-INF/container.xml", &container); err != nil {
+		return "", "", err
+	}
+	if len(container.Rootfiles) == 0 {
+		return "", "", fmt.Errorf("epub has no rootfile")
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := readZipXML(&zr.Reader, opfPath, &pkg); err != nil {
+		return "", "", err
+	}
+
+	hrefs := map[string]string{}
+	for _, item := range pkg.Manifest {
+		hrefs[item.ID] = item.Href
+	}
+	var sb strings.Builder
+	for _, ref := range pkg.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(href); err == nil {
+			href = unescaped
+		}
+		f, err := zr.Open(path.Join(path.Dir(opfPath), href))
+		if err != nil {
+			return "", "", err
+		}
+		doc, err := goquery.NewDocumentFromReader(f)
+		f.Close()
+		if err != nil {
+			return "", "", err
+		}
+		doc.Find("p, h1, h2, h3, li, blockquote").Each(func(_ int, s *goquery.Selection) {
+			if s.Children().Filter("p").Length() > 0 {
+				return
+			}
+			if text := strings.Join(strings.Fields(s.Text()), " "); text != "" {
+				sb.WriteString(text + "\n")
+			}
+		})
+	}
+	return strings.TrimSpace(pkg.Title), sb.String(), nil
+}
+
+func readZipXML(zr *zip.Reader, name string, into any) error {
+	f, err := zr.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	return xml.Unmarshal(data, into)
+}
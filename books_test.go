@@ -0,0 +1,104 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIngestBooksWithSameName(t *testing.T) {
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	src := t.TempDir()
+	for _, dir := range []string{"a", "b"} {
+		path := filepath.Join(src, dir, "cuentos.txt")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("El perro de "+dir+" duerme."), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ingestBook(path); err != nil {
+			t.Fatal(err)
+		}
+		// Ingesting the same file again replaces it.
+		if _, err := ingestBook(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	books, err := loadBooks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(books) != 2 {
+		t.Fatalf("loaded %d books, want 2", len(books))
+	}
+	if got := findBookExamples(books, "perro", 10); len(got) != 2 || got[0].Match != "perro" {
+		t.Errorf("found %+v, want a sentence from each book", got)
+	}
+}
+
+// Writes an EPUB whose spine lists the chapters in a different order from
+// the manifest, with a manifest item that is not in the spine.
+func writeTestEPUB(t *testing.T, path string) {
+	t.Helper()
+	files := []struct{ name, body string }{
+		{"mimetype", "application/epub+zip"},
+		{"META-INF/container.xml", `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`},
+		{"OEBPS/content.opf", `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <metadata xmlns:dc="http://purl.org/dc/elements/1.1/"><dc:title>Cuentos de la selva</dc:title></metadata>
+  <manifest>
+    <item id="nav" href="nav.xhtml" media-type="application/xhtml+xml" properties="nav"/>
+    <item id="c1" href="text/capitulo%201.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c2" href="text/capitulo2.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine><itemref idref="c2"/><itemref idref="c1"/></spine>
+</package>`},
+		{"OEBPS/nav.xhtml", `<html><body><nav><ol><li>Índice del libro.</li></ol></nav></body></html>`},
+		{"OEBPS/text/capitulo 1.xhtml", `<html><body><h1>Uno</h1><p>El perro duerme en la casa.</p></body></html>`},
+		{"OEBPS/text/capitulo2.xhtml", `<html><body><blockquote><p>La tortuga camina despacio.</p></blockquote></body></html>`},
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(file.body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIngestEPUB(t *testing.T) {
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	path := filepath.Join(t.TempDir(), "selva.epub")
+	writeTestEPUB(t, path)
+
+	b, err := ingestBook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Title != "Cuentos de la selva" {
+		t.Errorf("title %q", b.Title)
+	}
+	got := strings.Join(b.Sentences, " | ")
+	want := "La tortuga camina despacio. | Uno | El perro duerme en la casa."
+	if got != want {
+		t.Errorf("sentences %q, want the spine documents in spine order: %q", got, want)
+	}
+}
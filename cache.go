@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// llmCache stores LLM requests and responses on disk, addressed by a hash of
+// the model and prompts, so identical requests are answered without an API
+// call and return the same text every time.
+type llmCache struct {
+	dir string
+}
+
+type llmCacheEntry struct {
+	Model        string    `json:"model"`
+	SystemPrompt string    `json:"system_prompt"`
+	UserPrompt   string    `json:"user_prompt"`
+	Response     string    `json:"response"`
+	Created      time.Time `json:"created"`
+}
+
+func newLLMCache() (*llmCache, error) {
+	dir, err := dataPath("llmcache")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &llmCache{dir: dir}, nil
+}
+
+func llmCacheKey(model, systemPrompt, userPrompt string) string {
+	h := sha256.New()
+	for _, part := range []string{model, systemPrompt, userPrompt} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *llmCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+func (c *llmCache) get(key string) (*llmCacheEntry, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var entry llmCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *llmCache) put(key string, entry llmCacheEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path(key) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path(key))
+}
+
+// CachedChatCompletion answers from the response cache when possible and
+// otherwise calls ChatCompletion and caches the reply. fresh skips the lookup
+// but still stores the new response. A cache that cannot be read or written
+// is logged and bypassed rather than failing the call.
+func (c *OpenAIClient) CachedChatCompletion(purpose, systemPrompt, userPrompt string, fresh bool) (string, error) {
+	cache, err := newLLMCache()
+	if err != nil {
+		log.Printf("open LLM cache: %v", err)
+		return c.ChatCompletion(purpose, systemPrompt, userPrompt)
+	}
+	key := llmCacheKey(c.model, systemPrompt, userPrompt)
+	if !fresh {
+		if entry, ok := cache.get(key); ok {
+			return entry.Response, nil
+		}
+	}
+
+	result, err := c.ChatCompletion(purpose, systemPrompt, userPrompt)
+	if err != nil {
+		return "", err
+	}
+	if err := cache.put(key, llmCacheEntry{
+		Model:        c.model,
+		SystemPrompt: systemPrompt,
+		UserPrompt:   userPrompt,
+		Response:     result,
+		Created:      time.Now(),
+	}); err != nil {
+		log.Printf("cache LLM response: %v", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestCachedChatCompletion(t *testing.T) {
+	calls := startFakeOpenAI(t, "hola")
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	client := NewOpenAIClient(nil)
+
+	ask := func(user string, fresh bool) string {
+		t.Helper()
+		reply, err := client.CachedChatCompletion(purposePhrases, "system", user, fresh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply
+	}
+
+	if got := ask("dog", false); got != "hola #1" {
+		t.Errorf("miss gave %q", got)
+	}
+	if got := ask("dog", false); got != "hola #1" || calls.Load() != 1 {
+		t.Errorf("hit gave %q after %d calls, want the cached reply", got, calls.Load())
+	}
+	if got := ask("cat", false); got != "hola #2" {
+		t.Errorf("different prompt gave %q, want a new call", got)
+	}
+	if got := ask("dog", true); got != "hola #3" {
+		t.Errorf("fresh gave %q, want a new call", got)
+	}
+	if got := ask("dog", false); got != "hola #3" || calls.Load() != 3 {
+		t.Errorf("hit after fresh gave %q after %d calls, want the refreshed reply", got, calls.Load())
+	}
+}
+
+func TestCachedChatCompletionKeepsReplyWhenCacheFails(t *testing.T) {
+	startFakeOpenAI(t, "hola")
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	cache, err := newLLMCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A directory in the way of the temporary file makes put fail.
+	client := NewOpenAIClient(nil)
+	if err := os.Mkdir(cache.path(llmCacheKey(client.model, "system", "dog"))+".tmp", 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	reply, err := client.CachedChatCompletion(purposePhrases, "system", "dog", false)
+	if err != nil || reply != "hola #1" {
+		t.Errorf("got %q, %v; want the reply despite the cache error", reply, err)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// apiClients builds the Mochi and OpenAI clients commands use, all sending
+// their requests through one transport. The zero value uses
+// http.DefaultTransport; ANKIBUILDER_CASSETTE and tests set a cassette.
+type apiClients struct {
+	transport http.RoundTripper
+}
+
+// Returns a Mochi client for the MOCHI_KEY account.
+func (api apiClients) mochi() *MochiClient {
+	key, _ := os.LookupEnv("MOCHI_KEY")
+	return NewMochiClient(key, api.transport)
+}
+
+func (api apiClients) openAI() *OpenAIClient {
+	return NewOpenAIClient(api.transport)
+}
+
+// A nil transport means http.DefaultTransport.
+func newHTTPClient(transport http.RoundTripper) *http.Client {
+	return &http.Client{Transport: transport}
+}
+
+// One recorded request and its response. Request headers are not kept, so
+// API keys never end up in a cassette.
+type interaction struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	RequestBody string `json:"request_body,omitempty"`
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        string `json:"body"`
+}
+
+// cassette is a record/replay http.RoundTripper. When recording it passes
+// requests on to next and keeps every exchange; when replaying it answers
+// from the recorded exchanges, in order, and fails on anything unrecorded.
+type cassette struct {
+	path string
+	next http.RoundTripper // nil when replaying
+
+	mu           sync.Mutex
+	Interactions []interaction `json:"interactions"`
+	used         []bool
+}
+
+func loadCassette(path string) (*cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &cassette{path: path}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+	c.used = make([]bool, len(c.Interactions))
+	return c, nil
+}
+
+func recordCassette(path string, next http.RoundTripper) *cassette {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &cassette{path: path, next: next}
+}
+
+// Returns the transport ANKIBUILDER_CASSETTE selects, recording to the file
+// if ANKIBUILDER_RECORD is set and replaying from it otherwise, or nil when
+// it is not set. The returned function saves a recording and should run on
+// exit.
+func cassetteFromEnv() (http.RoundTripper, func() error, error) {
+	path := os.Getenv("ANKIBUILDER_CASSETTE")
+	if path == "" {
+		return nil, func() error { return nil }, nil
+	}
+	if os.Getenv("ANKIBUILDER_RECORD") != "" {
+		c := recordCassette(path, nil)
+		return c, c.Save, nil
+	}
+	c, err := loadCassette(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, func() error { return nil }, nil
+}
+
+func (c *cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+	if c.next != nil {
+		return c.record(req, reqBody)
+	}
+	return c.replay(req, reqBody)
+}
+
+func (c *cassette) record(req *http.Request, reqBody []byte) (*http.Response, error) {
+	resp, err := c.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, interaction{
+		Method:      req.Method,
+		URL:         req.URL.String(),
+		RequestBody: string(reqBody),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+	})
+	return resp, nil
+}
+
+func (c *cassette) replay(req *http.Request, reqBody []byte) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, in := range c.Interactions {
+		if c.used[i] || in.Method != req.Method || in.URL != req.URL.String() {
+			continue
+		}
+		if in.RequestBody != "" && !sameBody(in.RequestBody, reqBody) {
+			continue
+		}
+		c.used[i] = true
+		header := http.Header{}
+		if in.ContentType != "" {
+			header.Set("Content-Type", in.ContentType)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Status, http.StatusText(in.Status)),
+			StatusCode:    in.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewBufferString(in.Body)),
+			ContentLength: int64(len(in.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette %s has no recorded response for %s %s", c.path, req.Method, req.URL)
+}
+
+// JSON bodies match if they encode the same value, whatever the key order
+// or whitespace; anything else must match exactly.
+func sameBody(recorded string, body []byte) bool {
+	var a, b any
+	if json.Unmarshal([]byte(recorded), &a) == nil && json.Unmarshal(body, &b) == nil {
+		ja, _ := json.Marshal(a)
+		jb, _ := json.Marshal(b)
+		return bytes.Equal(ja, jb)
+	}
+	return recorded == string(body)
+}
+
+// Unused reports the recorded requests that were never replayed.
+func (c *cassette) Unused() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []string
+	for i, in := range c.Interactions {
+		if !c.used[i] {
+			out = append(out, in.Method+" "+in.URL)
+		}
+	}
+	return out
+}
+
+func (c *cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
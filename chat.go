@@ -2,10 +2,14 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -19,8 +23,9 @@ import (
 const pageSize = 5
 
 type Phrase struct {
-	Source string // Spanish sentence (plain, no ** markers)
-	Target string // English translation
+	Source     string   `json:"source"`               // Spanish sentence (plain, no ** markers)
+	Target     string   `json:"target"`               // English translation
+	Highlights []string `json:"highlights,omitempty"` // spans the LLM wrapped in ** markers
 }
 
 type chatModel struct {
@@ -29,14 +34,25 @@ type chatModel struct {
 	lastTranslation *Translation
 	lastWord        string
 	lastPhrases     []Phrase
+	mnemonics       map[int]string // entry index -> generated mnemonic notes
+	lastMined       []minedWord
+	suggestions     []string // "did you mean" words from the last failed lookup
+	lastConj        *Conjugation
+	conjTenses      []int    // selected tense indexes of lastConj, all if empty
+	entryExamples   []string // entry index -> mined sentence used as SourceExample
 	shownEntries    int
-	wr              *WordReference
+	verify          bool    // check card content with the LLM before creating
+	pendingCreate   tea.Cmd // card creation held back by failed verification
+	lookup          *wordLookup
+	api             apiClients
+	print           func(...any) tea.Cmd // tea.Println; tests collect the output instead
+	deadKeys        bool                 // n~ → ñ, a' → á while typing; off by default as it mangles "we're"
 	width           int
 	busy            bool
 	busyMsg         string
 }
 
-func newChatModel(wr *WordReference) chatModel {
+func newChatModel(lookup *wordLookup, api apiClients) chatModel {
 	ti := textinput.New()
 	ti.Placeholder = "Enter a word or /command (/help for list)"
 	ti.Prompt = "❯ "
@@ -51,7 +67,9 @@ func newChatModel(wr *WordReference) chatModel {
 	return chatModel{
 		textInput: ti,
 		spinner:   s,
-		wr:        wr,
+		lookup:    lookup,
+		api:       api,
+		print:     tea.Println,
 	}
 }
 
@@ -91,7 +109,7 @@ func (m chatModel) update(msg tea.Msg) (chatModel, tea.Cmd) {
 				return m, nil
 			}
 			m.textInput.Reset()
-			cmds = append(cmds, tea.Println(echoStyle.Render("> "+input)))
+			cmds = append(cmds, m.print(echoStyle.Render("> "+input)))
 
 			if strings.HasPrefix(input, "/") {
 				cmds = append(cmds, m.handleCommand(input)...)
@@ -100,64 +118,276 @@ func (m chatModel) update(msg tea.Msg) (chatModel, tea.Cmd) {
 
 			// Word lookup
 			cmds = append(cmds, m.setBusy(true, "Looking up"))
-			cmds = append(cmds, translateCmd(m.wr, input))
+			cmds = append(cmds, translateCmd(m.lookup, input))
 			return m, tea.Batch(cmds...)
 		}
 
+	case lookupWordMsg:
+		if m.busy {
+			return m, m.print(dimStyle.Render(fmt.Sprintf("Still busy; look up %q when the current command finishes.", msg.word)))
+		}
+		cmds = append(cmds, m.print(echoStyle.Render("> "+msg.word)))
+		cmds = append(cmds, m.setBusy(true, "Looking up"))
+		cmds = append(cmds, translateCmd(m.lookup, msg.word))
+		return m, tea.Batch(cmds...)
+
 	case translateResultMsg:
 		m.setBusy(false)
 		if msg.err != nil {
-			return m, tea.Println(errStyle.Render("Error: " + msg.err.Error()))
+			m.suggestions = msg.suggestions
+			output := errStyle.Render("Error: " + msg.err.Error())
+			if len(msg.suggestions) > 0 {
+				output += "\n" + renderSuggestions(msg.suggestions)
+			}
+			return m, m.print(output)
 		}
+		m.suggestions = nil
 		m.lastTranslation = msg.translation
 		m.lastWord = msg.word
+		m.mnemonics = nil
+		m.entryExamples = nil
+		if mw, ok := m.minedWord(msg.word); ok {
+			for range flattenEntries(msg.translation) {
+				m.entryExamples = append(m.entryExamples, mw.Sentence)
+			}
+		}
 		m.shownEntries = 0
 		entries := flattenEntries(msg.translation)
 		end := min(pageSize, len(entries))
 		m.shownEntries = end
 		output := renderEntries(msg.word, entries, 0, end)
+		if msg.lemma != nil {
+			output = renderLemma(cmp.Or(msg.restored, msg.word), msg.lemma) + "\n" + output
+		}
+		if msg.restored != "" {
+			output = renderRestored(msg.word, msg.restored) + "\n" + output
+		}
+		if msg.dir == dirReverse {
+			output = dimStyle.Render("  "+msg.dir.String()) + "\n" + output
+		}
 		if end < len(entries) {
 			output += "\n" + dimStyle.Render(fmt.Sprintf("  Showing %d of %d — /more for next page, /all for everything", end, len(entries)))
 		}
-		return m, tea.Println(output)
+		if msg.storeErr != nil {
+			output += "\n" + renderWarning(msg.storeErr)
+		}
+		return m, m.print(output)
+
+	case batchTranslateResultMsg:
+		m.setBusy(false)
+		merged := &Translation{}
+		var words []string
+		m.entryExamples = nil
+		for _, r := range msg.results {
+			if r.err != nil {
+				cmds = append(cmds, m.print(errStyle.Render(fmt.Sprintf("Error looking up %s: %s", r.word, r.err))))
+				continue
+			}
+			entries := flattenEntries(r.translation)
+			if len(entries) == 0 {
+				continue
+			}
+			title := cmp.Or(r.restored, r.word)
+			if r.lemma != nil {
+				title = fmt.Sprintf("%s = %s", title, r.lemma)
+			}
+			words = append(words, r.word)
+			merged.FromLang = cmp.Or(merged.FromLang, r.translation.FromLang)
+			merged.ToLang = cmp.Or(merged.ToLang, r.translation.ToLang)
+			for _, section := range r.translation.Translations {
+				section.Title = title + " — " + section.Title
+				merged.Translations = append(merged.Translations, section)
+			}
+			mw, _ := m.minedWord(r.word)
+			for range entries {
+				m.entryExamples = append(m.entryExamples, mw.Sentence)
+			}
+		}
+		if len(words) == 0 {
+			return m, tea.Batch(cmds...)
+		}
+		merged.Word = strings.Join(words, ", ")
+		m.lastTranslation = merged
+		m.lastWord = merged.Word
+		m.mnemonics = nil
+		entries := flattenEntries(merged)
+		m.shownEntries = len(entries)
+		cmds = append(cmds, m.print(renderEntries(merged.Word, entries, 0, len(entries))))
+		return m, tea.Batch(cmds...)
+
+	case pasteFinishedMsg:
+		m.setBusy(false)
+		if msg.err != nil {
+			return m, m.print(errStyle.Render("Editor error: " + msg.err.Error()))
+		}
+		content := msg.content
+		return m, tea.Batch(m.setBusy(true, "Mining vocabulary"), mineCmd(m.api, func() (string, error) {
+			return content, nil
+		}))
+
+	case mineResultMsg:
+		m.setBusy(false)
+		if msg.err != nil {
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
+		}
+		m.lastMined = msg.words
+		if len(msg.words) == 0 {
+			return m, m.print(dimStyle.Render("No new words found."))
+		}
+		return m, m.print(renderMined(msg.words, 50) + "\n" +
+			dimStyle.Render(fmt.Sprintf("  %d new word(s) — /lookup <n...> to translate them", len(msg.words))))
+
+	case bookIngestedMsg:
+		m.setBusy(false)
+		if msg.err != nil {
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
+		}
+		return m, m.print(successStyle.Render(fmt.Sprintf("Indexed %d sentences from %s.", len(msg.book.Sentences), msg.book.Title)))
+
+	case listBooksResultMsg:
+		m.setBusy(false)
+		if msg.err != nil {
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
+		}
+		if len(msg.books) == 0 {
+			return m, m.print(dimStyle.Render("No books yet. Use /book <file> to add one."))
+		}
+		return m, m.print(renderBooks(msg.books))
+
+	case bookExamplesMsg:
+		m.setBusy(false)
+		if msg.err != nil {
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
+		}
+		if len(msg.examples) == 0 {
+			return m, m.print(dimStyle.Render(fmt.Sprintf("No sentences with %q in your books.", msg.word)))
+		}
+		m.lastPhrases = Map(msg.examples, func(ex bookExample) Phrase {
+			return Phrase{Source: ex.Sentence, Highlights: []string{ex.Match}}
+		})
+		return m, m.print(renderBookExamples(msg.examples) + "\n" +
+			dimStyle.Render("  /cloze <n...> to make cards"))
+
+	case tatoebaImportedMsg:
+		m.setBusy(false)
+		if msg.err != nil {
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
+		}
+		return m, m.print(successStyle.Render(fmt.Sprintf("Imported %d sentence pairs.", msg.count)))
+
+	case tatoebaExamplesMsg:
+		m.setBusy(false)
+		if msg.err != nil {
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
+		}
+		if len(msg.phrases) == 0 {
+			return m, m.print(dimStyle.Render(fmt.Sprintf("No Tatoeba sentences with %q.", msg.word)))
+		}
+		m.lastPhrases = msg.phrases
+		return m, m.print(renderPhrases(m.lastPhrases))
+
+	case conjugationResultMsg:
+		m.setBusy(false)
+		if msg.err != nil {
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
+		}
+		var tenses []int
+		for _, n := range msg.selected {
+			if n < 1 || n > len(msg.conj.Tenses) {
+				return m, m.print(errStyle.Render(fmt.Sprintf("Invalid tense: %d (must be 1-%d)", n, len(msg.conj.Tenses))))
+			}
+			tenses = append(tenses, n-1)
+		}
+		m.lastConj, m.conjTenses = msg.conj, tenses
+		return m, m.print(renderConjugation(m.lastConj, m.conjTenses, m.width) + "\n" +
+			dimStyle.Render("  /conj <n...> to pick tenses, /drill to make cards from them"))
 
 	case listDecksResultMsg:
 		m.setBusy(false)
 		if msg.err != nil {
-			return m, tea.Println(errStyle.Render("Error: " + msg.err.Error()))
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
 		}
-		return m, tea.Println(renderDecks(msg.decks))
+		return m, m.print(renderDecks(msg.decks))
 
 	case listTemplatesResultMsg:
 		m.setBusy(false)
 		if msg.err != nil {
-			return m, tea.Println(errStyle.Render("Error: " + msg.err.Error()))
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
 		}
-		return m, tea.Println(renderTemplates(msg.templates))
+		return m, m.print(renderTemplates(msg.templates))
 
 	case editorFinishedMsg:
 		if msg.err != nil {
 			m.setBusy(false)
-			return m, tea.Println(errStyle.Render("Editor error: " + msg.err.Error()))
+			return m, m.print(errStyle.Render("Editor error: " + msg.err.Error()))
+		}
+		if m.verify {
+			cmds = append(cmds, m.setBusy(true, "Verifying cards"))
+			cmds = append(cmds, verifyTemplateCmd(m.api, msg.content, m.lastTranslation.FromLang, m.lastTranslation.ToLang))
+			return m, tea.Batch(cmds...)
 		}
 		cmds = append(cmds, m.setBusy(true, "Creating cards"))
-		cmds = append(cmds, createCardsCmd(msg.content))
+		cmds = append(cmds, createCardsCmd(m.api, msg.content))
+		return m, tea.Batch(cmds...)
+
+	case verifyResultMsg:
+		m.setBusy(false)
+		if msg.err != nil {
+			output := errStyle.Render("Could not verify the cards: " + msg.err.Error())
+			if msg.create != nil {
+				// Keep the edited cards so a failed check does not lose them.
+				m.pendingCreate = msg.create
+				output += "\n" + dimStyle.Render("  /confirm to create anyway, /cancel to discard")
+			}
+			return m, m.print(output)
+		}
+		for _, v := range msg.results {
+			if !v.OK {
+				m.pendingCreate = msg.create
+				return m, m.print(renderVerifications(msg.results) + "\n" +
+					dimStyle.Render("  Problems found — /confirm to create anyway, /cancel to discard"))
+			}
+		}
+		cmds = append(cmds, m.print(renderVerifications(msg.results)))
+		cmds = append(cmds, m.setBusy(true, "Creating cards"), msg.create)
 		return m, tea.Batch(cmds...)
 
 	case addCardResultMsg:
 		m.setBusy(false)
 		if msg.err != nil {
-			return m, tea.Println(errStyle.Render("Error: " + msg.err.Error()))
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
 		}
-		return m, tea.Println(successStyle.Render(fmt.Sprintf("Successfully created %d card(s).", msg.count)))
+		output := successStyle.Render(fmt.Sprintf("Successfully created %d card(s).", msg.count))
+		if msg.storeErr != nil {
+			output += "\n" + renderWarning(msg.storeErr)
+		}
+		return m, m.print(output)
 
 	case phrasesResultMsg:
 		m.setBusy(false)
 		if msg.err != nil {
-			return m, tea.Println(errStyle.Render("Error: " + msg.err.Error()))
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
 		}
 		m.lastPhrases = parsePhrases(msg.phrases)
-		return m, tea.Println(renderPhrases(m.lastPhrases))
+		return m, m.print(renderPhrases(m.lastPhrases))
+
+	case mnemonicResultMsg:
+		m.setBusy(false)
+		if msg.err != nil {
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
+		}
+		if m.mnemonics == nil {
+			m.mnemonics = map[int]string{}
+		}
+		m.mnemonics[msg.index] = msg.mnemonic
+		return m, m.print(renderMnemonic(msg.mnemonic) + "\n" +
+			dimStyle.Render(fmt.Sprintf("  Added as notes by /add %d — edit it there before saving", msg.index+1)))
+
+	case usageResultMsg:
+		if msg.err != nil {
+			return m, m.print(errStyle.Render("Error: " + msg.err.Error()))
+		}
+		return m, m.print(renderUsage(msg.report))
 
 	case spinner.TickMsg:
 		if m.busy {
@@ -171,6 +401,9 @@ func (m chatModel) update(msg tea.Msg) (chatModel, tea.Cmd) {
 	// Pass remaining messages to textinput
 	var cmd tea.Cmd
 	m.textInput, cmd = m.textInput.Update(msg)
+	if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyRunes && m.deadKeys {
+		applyDeadKeys(&m.textInput)
+	}
 	if cmd != nil {
 		cmds = append(cmds, cmd)
 	}
@@ -187,10 +420,16 @@ func (m chatModel) view() string {
 		if m.shownEntries < len(entries) {
 			hints = append(hints, "/more", "/all")
 		}
-		hints = append(hints, "/add", "/phrases")
+		hints = append(hints, "/add", "/phrases", "/mnemonic", "/sentences")
 	}
 	if len(m.lastPhrases) > 0 {
-		hints = append(hints, "/cards")
+		hints = append(hints, "/cards", "/cloze")
+	}
+	if len(m.suggestions) > 0 {
+		hints = append(hints, "/try")
+	}
+	if m.pendingCreate != nil {
+		hints = append(hints, "/confirm", "/cancel")
 	}
 	hints = append(hints, "/help")
 	return m.textInput.View() + "\n" + dimStyle.Render(strings.Join(hints, " "))
@@ -201,23 +440,48 @@ func (m *chatModel) handleCommand(input string) []tea.Cmd {
 	switch parts[0] {
 	case "/help":
 		help := helpStyle.Render("Commands:") + "\n" +
-			"  /more        — show next page of results\n" +
-			"  /all         — show all remaining results\n" +
-			"  /decks       — list decks\n" +
-			"  /templates   — list templates\n" +
-			"  /add [n]     — add card from translation row n\n" +
-			"  /phrases <n> — generate example sentences for entry n\n" +
-			"  /cards [n]   — create cards from phrase n (e.g. /cards 1 3 5)\n" +
-			"  /help        — show this help"
-		return []tea.Cmd{tea.Println(help)}
+			"  /more            — show next page of results\n" +
+			"  /all             — show all remaining results\n" +
+			"  /show [kind]     — group results by section (principal, compound, ...)\n" +
+			"  /pos <pos>       — only show nouns, verbs, adjectives, ...\n" +
+			"  /phrase <n...>   — use compound forms or idioms n as phrases for /cards\n" +
+			"  /decks           — list decks\n" +
+			"  /templates       — list templates\n" +
+			"  /pairs           — list the dictionary's language pairs\n" +
+			"  /try <n>         — look up suggestion n after a failed lookup\n" +
+			"  /deadkeys        — toggle n~ → ñ, a' → á style shortcuts (off by default)\n" +
+			"  /from <en|es>    — set the input language (auto to detect)\n" +
+			"  /to <en|es>      — set the output language (auto to detect)\n" +
+			"  /add [n]         — add card from translation row n\n" +
+			"  /phrases <n>     — generate example sentences for entry n (--fresh skips cache)\n" +
+			"  /mnemonic <n>    — generate a memory hook and etymology for entry n\n" +
+			"  /cards [n]       — create cards from phrase n (e.g. /cards 1 3 5)\n" +
+			"  /cloze [n]       — create cloze cards from phrase n\n" +
+			"  /verify [on|off] — check card content with the LLM before upload\n" +
+			"  /confirm         — create cards that failed verification anyway\n" +
+			"  /cancel          — discard cards that failed verification\n" +
+			"  /usage           — show LLM token usage and cost\n" +
+			"  /conj <verb>     — show a verb's conjugation table\n" +
+			"  /conj <n...>     — pick tenses from the last conjugation\n" +
+			"  /drill [n...]    — create drill cards per person (--persons=yo,tú; --table for one per tense)\n" +
+			"  /tutor           — practice conversation with an LLM tutor\n" +
+			"  /mine [file]     — find new words in a text or .srt file (or pasted text)\n" +
+			"  /lookup <n...>   — translate mined words n\n" +
+			"  /book <file>     — index an EPUB or text book for example sentences\n" +
+			"  /books           — list indexed books\n" +
+			"  /sentences [w]   — show book sentences using the word\n" +
+			"  /tatoeba <s> <l> — import Tatoeba sentences and links dumps\n" +
+			"  /examples <word> — show Tatoeba sentence pairs using the word\n" +
+			"  /help            — show this help"
+		return []tea.Cmd{m.print(help)}
 
 	case "/more":
 		if m.lastTranslation == nil {
-			return []tea.Cmd{tea.Println(errStyle.Render("No results to show."))}
+			return []tea.Cmd{m.print(errStyle.Render("No results to show."))}
 		}
 		entries := flattenEntries(m.lastTranslation)
 		if m.shownEntries >= len(entries) {
-			return []tea.Cmd{tea.Println(dimStyle.Render("No more results."))}
+			return []tea.Cmd{m.print(dimStyle.Render("No more results."))}
 		}
 		start := m.shownEntries
 		end := min(start+pageSize, len(entries))
@@ -226,80 +490,518 @@ func (m *chatModel) handleCommand(input string) []tea.Cmd {
 		if end < len(entries) {
 			output += "\n" + dimStyle.Render(fmt.Sprintf("  Showing %d of %d — /more for next page, /all for everything", end, len(entries)))
 		}
-		return []tea.Cmd{tea.Println(output)}
+		return []tea.Cmd{m.print(output)}
 
 	case "/all":
 		if m.lastTranslation == nil {
-			return []tea.Cmd{tea.Println(errStyle.Render("No results to show."))}
+			return []tea.Cmd{m.print(errStyle.Render("No results to show."))}
 		}
 		entries := flattenEntries(m.lastTranslation)
 		if m.shownEntries >= len(entries) {
-			return []tea.Cmd{tea.Println(dimStyle.Render("No more results."))}
+			return []tea.Cmd{m.print(dimStyle.Render("No more results."))}
 		}
 		start := m.shownEntries
 		m.shownEntries = len(entries)
-		return []tea.Cmd{tea.Println(renderEntries(m.lastWord, entries, start, len(entries)))}
+		return []tea.Cmd{m.print(renderEntries(m.lastWord, entries, start, len(entries)))}
 
 	case "/decks":
-		return []tea.Cmd{m.setBusy(true, "Loading decks"), listDecksCmd()}
+		return []tea.Cmd{m.setBusy(true, "Loading decks"), listDecksCmd(m.api)}
 
 	case "/templates":
-		return []tea.Cmd{m.setBusy(true, "Loading templates"), listTemplatesCmd()}
+		return []tea.Cmd{m.setBusy(true, "Loading templates"), listTemplatesCmd(m.api)}
 
 	case "/add":
 		if m.lastTranslation == nil {
-			return []tea.Cmd{tea.Println(errStyle.Render("No translation to add from. Look up a word first."))}
+			return []tea.Cmd{m.print(errStyle.Render("No translation to add from. Look up a word first."))}
 		}
 		if len(parts) < 2 {
-			return []tea.Cmd{tea.Println(errStyle.Render("Usage: /add [index...]"))}
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /add [index...]"))}
 		}
 		return []tea.Cmd{m.prepareAdd(parts[1:])}
 
 	case "/phrases":
 		if m.lastTranslation == nil {
-			return []tea.Cmd{tea.Println(errStyle.Render("No translation available. Look up a word first."))}
+			return []tea.Cmd{m.print(errStyle.Render("No translation available. Look up a word first."))}
 		}
-		if len(parts) < 2 {
-			return []tea.Cmd{tea.Println(errStyle.Render("Usage: /phrases <n>"))}
+		args, fresh := extractFlag(parts[1:], "--fresh")
+		if len(args) < 1 {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /phrases <n> [--fresh]"))}
 		}
-		n, err := strconv.Atoi(parts[1])
-		if err != nil {
-			return []tea.Cmd{tea.Println(errStyle.Render(fmt.Sprintf("%q is not a number", parts[1])))}
+		entries := flattenEntries(m.lastTranslation)
+		idx, errCmd := m.parseIndex(args[0], len(entries))
+		if errCmd != nil {
+			return []tea.Cmd{errCmd}
+		}
+		return []tea.Cmd{m.setBusy(true, "Generating example sentences"), phrasesCmd(m.api, entries[idx], fresh)}
+
+	case "/mnemonic":
+		if m.lastTranslation == nil {
+			return []tea.Cmd{m.print(errStyle.Render("No translation available. Look up a word first."))}
+		}
+		if len(parts) < 2 {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /mnemonic <n>"))}
 		}
 		entries := flattenEntries(m.lastTranslation)
-		idx := n - 1
-		if idx < 0 || idx >= len(entries) {
-			return []tea.Cmd{tea.Println(errStyle.Render(fmt.Sprintf("Invalid index: %d (must be 1-%d)", n, len(entries))))}
+		idx, errCmd := m.parseIndex(parts[1], len(entries))
+		if errCmd != nil {
+			return []tea.Cmd{errCmd}
 		}
-		return []tea.Cmd{m.setBusy(true, "Generating example sentences"), phrasesCmd(entries[idx])}
+		return []tea.Cmd{m.setBusy(true, "Generating mnemonic"), mnemonicCmd(m.api, idx, entries[idx])}
 
-	case "/cards", "/card":
+	case "/cards", "/card", "/cloze":
 		if len(m.lastPhrases) == 0 {
-			return []tea.Cmd{tea.Println(errStyle.Render("No phrases available. Use /phrases <n> first."))}
+			return []tea.Cmd{m.print(errStyle.Render("No phrases available. Use /phrases <n> first."))}
 		}
 		if len(parts) < 2 {
-			return []tea.Cmd{tea.Println(errStyle.Render("Usage: /cards [n...] (e.g. /cards 1 3 5)"))}
+			return []tea.Cmd{m.print(errStyle.Render(fmt.Sprintf("Usage: %s [n...] (e.g. %s 1 3 5)", parts[0], parts[0])))}
+		}
+		kind := cardKindBasic
+		if parts[0] == "/cloze" {
+			kind = cardKindCloze
 		}
 		var phrases []Phrase
 		for _, arg := range parts[1:] {
 			n, err := strconv.Atoi(arg)
 			if err != nil {
-				return []tea.Cmd{tea.Println(errStyle.Render(fmt.Sprintf("%q is not a number", arg)))}
+				return []tea.Cmd{m.print(errStyle.Render(fmt.Sprintf("%q is not a number", arg)))}
 			}
 			idx := n - 1
 			if idx < 0 || idx >= len(m.lastPhrases) {
-				return []tea.Cmd{tea.Println(errStyle.Render(fmt.Sprintf("Invalid index: %d (must be 1-%d)", n, len(m.lastPhrases))))}
+				return []tea.Cmd{m.print(errStyle.Render(fmt.Sprintf("Invalid index: %d (must be 1-%d)", n, len(m.lastPhrases))))}
+			}
+			if kind == cardKindBasic && m.lastPhrases[idx].Target == "" {
+				return []tea.Cmd{m.print(errStyle.Render(fmt.Sprintf("Phrase %d has no translation; use /cloze %d instead.", n, n)))}
 			}
 			phrases = append(phrases, m.lastPhrases[idx])
 		}
-		return []tea.Cmd{m.setBusy(true, "Creating cards"), createPhraseCardsCmd(phrases)}
+		if m.verify {
+			return []tea.Cmd{m.setBusy(true, "Verifying cards"), verifyPhrasesCmd(m.api, phrases, kind)}
+		}
+		return []tea.Cmd{m.setBusy(true, "Creating cards"), createPhraseCardsCmd(m.api, phrases, kind)}
+
+	case "/verify":
+		if len(parts) > 1 {
+			switch parts[1] {
+			case "on":
+				m.verify = true
+			case "off":
+				m.verify = false
+			default:
+				return []tea.Cmd{m.print(errStyle.Render("Usage: /verify [on|off]"))}
+			}
+		} else {
+			m.verify = !m.verify
+		}
+		state := "off"
+		if m.verify {
+			state = "on"
+		}
+		return []tea.Cmd{m.print(dimStyle.Render("Verification before upload is " + state + "."))}
+
+	case "/mine":
+		if len(parts) < 2 {
+			return []tea.Cmd{m.pasteText()}
+		}
+		path := strings.Join(parts[1:], " ")
+		return []tea.Cmd{m.setBusy(true, "Mining vocabulary"), mineCmd(m.api, func() (string, error) {
+			return readMineSource(path)
+		})}
+
+	case "/lookup":
+		if len(m.lastMined) == 0 {
+			return []tea.Cmd{m.print(errStyle.Render("No mined words. Use /mine first."))}
+		}
+		if len(parts) < 2 {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /lookup <n...> (e.g. /lookup 1 2 3)"))}
+		}
+		var words []string
+		for _, arg := range parts[1:] {
+			idx, errCmd := m.parseIndex(arg, len(m.lastMined))
+			if errCmd != nil {
+				return []tea.Cmd{errCmd}
+			}
+			words = append(words, m.lastMined[idx].Word)
+		}
+		// Mined words come from Spanish text, so skip direction detection.
+		return []tea.Cmd{m.setBusy(true, "Looking up"), batchTranslateCmd(m.lookup.withDirection(dirReverse), words)}
+
+	case "/book":
+		if len(parts) < 2 {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /book <file.epub|file.txt>"))}
+		}
+		return []tea.Cmd{m.setBusy(true, "Indexing book"), ingestBookCmd(strings.Join(parts[1:], " "))}
+
+	case "/books":
+		return []tea.Cmd{m.setBusy(true, "Loading books"), listBooksCmd()}
+
+	case "/sentences":
+		word := m.lastSpanishWord()
+		if len(parts) > 1 {
+			word = strings.Join(parts[1:], " ")
+		}
+		if word == "" {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /sentences [word]"))}
+		}
+		return []tea.Cmd{m.setBusy(true, "Searching books"), bookExamplesCmd(word)}
+
+	case "/tatoeba":
+		if len(parts) != 3 {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /tatoeba <sentences.csv> <links.csv>"))}
+		}
+		return []tea.Cmd{m.setBusy(true, "Importing Tatoeba sentences"), importTatoebaCmd(parts[1], parts[2])}
+
+	case "/examples":
+		word := m.lastSpanishWord()
+		if len(parts) > 1 {
+			word = strings.Join(parts[1:], " ")
+		}
+		if word == "" {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /examples <word>"))}
+		}
+		return []tea.Cmd{m.setBusy(true, "Searching examples"), tatoebaExamplesCmd(word)}
+
+	case "/try":
+		if len(m.suggestions) == 0 {
+			return []tea.Cmd{m.print(errStyle.Render("No suggestions available."))}
+		}
+		if len(parts) < 2 {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /try <n>"))}
+		}
+		idx, errCmd := m.parseIndex(parts[1], len(m.suggestions))
+		if errCmd != nil {
+			return []tea.Cmd{errCmd}
+		}
+		return []tea.Cmd{m.setBusy(true, "Looking up"), translateCmd(m.lookup, m.suggestions[idx])}
+
+	case "/from", "/to":
+		if len(parts) < 2 {
+			return []tea.Cmd{m.print(dimStyle.Render("Lookup direction: " + m.lookup.dir.String()))}
+		}
+		if m.lookup.reverse == nil {
+			return []tea.Cmd{m.print(errStyle.Render("No reverse dictionary available."))}
+		}
+		// "/from es" and "/to en" both mean the input is Spanish.
+		switch parts[0] + " " + strings.ToLower(parts[1]) {
+		case "/from auto", "/to auto":
+			m.lookup.dir = dirAuto
+		case "/from es", "/to en":
+			m.lookup.dir = dirReverse
+		case "/from en", "/to es":
+			m.lookup.dir = dirForward
+		default:
+			return []tea.Cmd{m.print(errStyle.Render(fmt.Sprintf("Usage: %s <en|es|auto>", parts[0])))}
+		}
+		return []tea.Cmd{m.print(dimStyle.Render("Lookup direction: " + m.lookup.dir.String()))}
+
+	case "/show":
+		if m.lastTranslation == nil {
+			return []tea.Cmd{m.print(errStyle.Render("No results to show."))}
+		}
+		var kind SectionKind
+		if len(parts) > 1 && parts[1] != "all" {
+			kind = SectionKind(strings.ToLower(parts[1]))
+			if !slices.Contains(sectionKinds, kind) {
+				names := Map(sectionKinds, func(k SectionKind) string { return string(k) })
+				return []tea.Cmd{m.print(errStyle.Render("Usage: /show [" + strings.Join(names, "|") + "|all]"))}
+			}
+		}
+		m.shownEntries = len(flattenEntries(m.lastTranslation))
+		return []tea.Cmd{m.print(renderSections(m.lastWord, m.lastTranslation, kind))}
+
+	case "/pos":
+		if m.lastTranslation == nil {
+			return []tea.Cmd{m.print(errStyle.Render("No results to filter."))}
+		}
+		names := Map(partsOfSpeech, func(p PartOfSpeech) string { return string(p) })
+		if len(parts) < 2 {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /pos <" + strings.Join(names, "|") + ">"))}
+		}
+		pos, ok := findPOS(parts[1])
+		if !ok {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /pos <" + strings.Join(names, "|") + ">"))}
+		}
+		entries := flattenEntries(m.lastTranslation)
+		m.shownEntries = len(entries)
+		return []tea.Cmd{m.print(renderEntriesWithPOS(m.lastWord, entries, pos))}
+
+	case "/phrase":
+		if m.lastTranslation == nil {
+			return []tea.Cmd{m.print(errStyle.Render("No translation available. Look up a word first."))}
+		}
+		if len(parts) < 2 {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /phrase <n...>"))}
+		}
+		var phrases []Phrase
+		for _, arg := range parts[1:] {
+			idx, errCmd := m.parseIndex(arg, len(flattenEntries(m.lastTranslation)))
+			if errCmd != nil {
+				return []tea.Cmd{errCmd}
+			}
+			entry, kind := entryAt(m.lastTranslation, idx)
+			if !kind.IsPhrase() {
+				return []tea.Cmd{m.print(errStyle.Render(fmt.Sprintf("Entry %s is a %s translation, not a compound form or idiom.", arg, kind)))}
+			}
+			phrases = append(phrases, entryPhrase(entry, m.lastSpanishWord(), languageCode(m.lastTranslation.FromLang) == "es"))
+		}
+		m.lastPhrases = phrases
+		return []tea.Cmd{m.print(renderPhrases(phrases) + "\n" + dimStyle.Render("  /cards <n...> or /cloze <n...> to make cards"))}
+
+	case "/deadkeys":
+		m.deadKeys = !m.deadKeys
+		state := "off"
+		if m.deadKeys {
+			state = "on"
+		}
+		return []tea.Cmd{m.print(dimStyle.Render("Dead-key shortcuts (n~ → ñ, a' → á) are " + state + "."))}
+
+	case "/pairs":
+		return []tea.Cmd{m.print(renderPairs(m.lookup.primary()))}
+
+	case "/conj":
+		if len(parts) < 2 {
+			return []tea.Cmd{m.print(errStyle.Render("Usage: /conj <verb> [tense n...] or /conj <tense n...>"))}
+		}
+		var verb string
+		var selected []int
+		for _, arg := range parts[1:] {
+			if n, err := strconv.Atoi(arg); err == nil {
+				selected = append(selected, n)
+			} else {
+				verb = strings.TrimSpace(verb + " " + arg)
+			}
+		}
+		if verb == "" {
+			if m.lastConj == nil {
+				return []tea.Cmd{m.print(errStyle.Render("No conjugation to pick tenses from. Use /conj <verb> first."))}
+			}
+			return []tea.Cmd{func() tea.Msg {
+				return conjugationResultMsg{conj: m.lastConj, selected: selected}
+			}}
+		}
+		c, ok := conjugatorOf(m.lookup.primary())
+		if !ok {
+			return []tea.Cmd{m.print(errStyle.Render(m.lookup.primary().Name() + " cannot conjugate verbs."))}
+		}
+		return []tea.Cmd{m.setBusy(true, "Conjugating"), conjugateCmd(c, verb, selected)}
+
+	case "/drill":
+		if m.lastConj == nil {
+			return []tea.Cmd{m.print(errStyle.Render("No conjugation available. Use /conj <verb> first."))}
+		}
+		args, wholeTense := extractFlag(parts[1:], "--table")
+		indexes := m.conjTenses
+		var persons []string
+		var picked []int
+		for _, arg := range args {
+			if p, ok := strings.CutPrefix(arg, "--persons="); ok {
+				persons = strings.Split(p, ",")
+				continue
+			}
+			idx, errCmd := m.parseIndex(arg, len(m.lastConj.Tenses))
+			if errCmd != nil {
+				return []tea.Cmd{errCmd}
+			}
+			picked = append(picked, idx)
+		}
+		if len(picked) > 0 {
+			indexes = picked
+		}
+		if len(indexes) == 0 {
+			return []tea.Cmd{m.print(errStyle.Render("Pick tenses first with /conj <n...> or /drill <n...>."))}
+		}
+		tenses := Map(indexes, func(i int) ConjTense { return m.lastConj.Tenses[i] })
+		return []tea.Cmd{m.setBusy(true, "Creating cards"), createDrillCardsCmd(m.api, m.lastConj, tenses, persons, wholeTense)}
+
+	case "/tutor":
+		return []tea.Cmd{switchModeCmd(modeTutor)}
+
+	case "/usage":
+		return []tea.Cmd{usageCmd()}
+
+	case "/confirm":
+		if m.pendingCreate == nil {
+			return []tea.Cmd{m.print(errStyle.Render("Nothing waiting for confirmation."))}
+		}
+		create := m.pendingCreate
+		m.pendingCreate = nil
+		return []tea.Cmd{m.setBusy(true, "Creating cards"), create}
+
+	case "/cancel":
+		if m.pendingCreate == nil {
+			return []tea.Cmd{m.print(errStyle.Render("Nothing waiting for confirmation."))}
+		}
+		m.pendingCreate = nil
+		return []tea.Cmd{m.print(dimStyle.Render("Discarded unverified cards."))}
 
 	default:
-		return []tea.Cmd{tea.Println(errStyle.Render(fmt.Sprintf("Unknown command: %s", parts[0])))}
+		return []tea.Cmd{m.print(errStyle.Render(fmt.Sprintf("Unknown command: %s", parts[0])))}
+	}
+}
+
+// Parses a 1-based index argument, returning the 0-based index or a command
+// printing the error.
+func (m *chatModel) parseIndex(arg string, count int) (int, tea.Cmd) {
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, m.print(errStyle.Render(fmt.Sprintf("%q is not a number", arg)))
+	}
+	idx := n - 1
+	if idx < 0 || idx >= count {
+		return 0, m.print(errStyle.Render(fmt.Sprintf("Invalid index: %d (must be 1-%d)", n, count)))
+	}
+	return idx, nil
+}
+
+func editorCommand() string {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = os.Getenv("VISUAL")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	return editor
+}
+
+// Opens the editor on an empty file for pasting text to mine.
+func (m *chatModel) pasteText() tea.Cmd {
+	tmpFile, err := os.CreateTemp("", "mine_*.txt")
+	if err != nil {
+		return m.print(errStyle.Render(fmt.Sprintf("Failed to create temp file: %s", err)))
+	}
+	tmpFile.Close()
+	tmpPath := tmpFile.Name()
+
+	m.setBusy(true)
+	c := exec.Command(editorCommand(), tmpPath)
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		if err != nil {
+			return pasteFinishedMsg{err: err}
+		}
+		content, readErr := os.ReadFile(tmpPath)
+		os.Remove(tmpPath)
+		return pasteFinishedMsg{content: string(content), err: readErr}
+	})
+}
+
+// Returns the Spanish side of the last lookup: the word itself when it was
+// looked up as Spanish, otherwise the first translation of the first entry.
+func (m *chatModel) lastSpanishWord() string {
+	t := m.lastTranslation
+	if t == nil {
+		return ""
+	}
+	if languageCode(t.FromLang) == "es" {
+		return m.lastWord
+	}
+	for _, entry := range flattenEntries(t) {
+		for _, tw := range entry.ToWords {
+			if w := knownWord(tw.Meaning); w != "" {
+				return w
+			}
+		}
+	}
+	return ""
+}
+
+func (m *chatModel) minedWord(word string) (minedWord, bool) {
+	for _, mw := range m.lastMined {
+		if mw.Word == word {
+			return mw, true
+		}
+	}
+	return minedWord{}, false
+}
+
+// Describes the sense an entry covers for the card's context field, e.g.
+// "animal — informal, UK".
+func entryContext(entry ParsedEntry) string {
+	labels := entry.Labels.String()
+	switch {
+	case entry.Context != "" && labels != "":
+		return entry.Context + " — " + labels
+	case entry.Context != "":
+		return entry.Context
+	}
+	return labels
+}
+
+// Returns the entry at flattened index idx and the kind of its section.
+func entryAt(t *Translation, idx int) (ParsedEntry, SectionKind) {
+	for _, section := range t.Translations {
+		if idx < len(section.Entries) {
+			return section.Entries[idx], section.Kind
+		}
+		idx -= len(section.Entries)
+	}
+	return ParsedEntry{}, SectionOther
+}
+
+// Turns a compound form or idiom entry into a phrase with the Spanish word
+// highlighted, so it can go through the phrase card pipeline. The Spanish
+// side is the entry's source when fromSpanish is set, otherwise its first
+// translation, highlighted whole if it does not contain word.
+func entryPhrase(entry ParsedEntry, word string, fromSpanish bool) Phrase {
+	meanings := Map(entry.ToWords, func(tw ToWord) string { return tw.Meaning })
+	var p Phrase
+	if fromSpanish {
+		p = Phrase{Source: entry.FromWord.Source, Target: strings.Join(meanings, "; ")}
+	} else if len(meanings) > 0 {
+		p = Phrase{Source: meanings[0], Target: entry.FromWord.Source}
+	}
+	if word != "" && strings.Contains(strings.ToLower(p.Source), strings.ToLower(word)) {
+		p.Highlights = []string{matchedForm(p.Source, strings.ToLower(word))}
+	} else if !fromSpanish && p.Source != "" {
+		p.Highlights = []string{p.Source}
+	}
+	return p
+}
+
+// Removes flag from args, reporting whether it was present.
+func extractFlag(args []string, flag string) ([]string, bool) {
+	var rest []string
+	found := false
+	for _, arg := range args {
+		if arg == flag {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
 	}
+	return rest, found
 }
 
 func (m *chatModel) prepareAdd(params []string) tea.Cmd {
+	content, err := m.addTemplates(params)
+	if err != nil {
+		return m.print(errStyle.Render(err.Error()))
+	}
+
+	tmpFile, err := os.CreateTemp("", "wr_*.yml")
+	if err != nil {
+		return m.print(errStyle.Render(fmt.Sprintf("Failed to create temp file: %s", err)))
+	}
+	if _, err := io.WriteString(tmpFile, content); err != nil {
+		return m.print(errStyle.Render(fmt.Sprintf("Failed to write temp file: %s", err)))
+	}
+	tmpFile.Close()
+	tmpPath := tmpFile.Name()
+
+	m.setBusy(true)
+	c := exec.Command(editorCommand(), tmpPath)
+	return tea.ExecProcess(c, func(err error) tea.Msg {
+		if err != nil {
+			return editorFinishedMsg{err: err}
+		}
+		content, readErr := os.ReadFile(tmpPath)
+		os.Remove(tmpPath)
+		return editorFinishedMsg{content: string(content), err: readErr}
+	})
+}
+
+// Builds the YAML card templates /add opens in the editor, one document per
+// 1-based entry index in params.
+func (m *chatModel) addTemplates(params []string) (string, error) {
 	allEntries := []ParsedEntry{}
 	for _, section := range m.lastTranslation.Translations {
 		for _, entry := range section.Entries {
@@ -311,7 +1013,7 @@ func (m *chatModel) prepareAdd(params []string) tea.Cmd {
 	for _, param := range params {
 		n, err := strconv.Atoi(param)
 		if err != nil {
-			return tea.Println(errStyle.Render(fmt.Sprintf("%q is not a number", param)))
+			return "", fmt.Errorf("%q is not a number", param)
 		}
 		paramNums = append(paramNums, n)
 	}
@@ -321,12 +1023,15 @@ func (m *chatModel) prepareAdd(params []string) tea.Cmd {
 	for _, n := range paramNums {
 		idx := n - 1
 		if idx < 0 || idx >= len(allEntries) {
-			return tea.Println(errStyle.Render(fmt.Sprintf("Invalid index: %d", n)))
+			return "", fmt.Errorf("Invalid index: %d", n)
 		}
 		entry := allEntries[idx]
+		fw := entry.FromWord
+		fw.Source = withArticle(m.lastTranslation.FromLang, fw.Source, fw.Tags)
 		templ := EditTemplate{
-			TargetLang: entry.FromWord.String(),
+			TargetLang: fw.String(),
 			SourceLang: strings.Join(Map(entry.ToWords, func(tw ToWord) string {
+				tw.Meaning = withArticle(m.lastTranslation.ToLang, tw.Meaning, tw.Tags)
 				return tw.String()
 			}), "\n"),
 		}
@@ -336,39 +1041,16 @@ func (m *chatModel) prepareAdd(params []string) tea.Cmd {
 		if len(entry.ToExample) > 0 {
 			templ.TargetExample = entry.ToExample[0]
 		}
+		templ.Context = entryContext(entry)
+		templ.Notes = m.mnemonics[idx]
+		if idx < len(m.entryExamples) && m.entryExamples[idx] != "" {
+			templ.SourceExample = m.entryExamples[idx]
+		}
 		if err := enc.Encode(templ); err != nil {
-			return tea.Println(errStyle.Render(fmt.Sprintf("YAML encode error: %s", err)))
+			return "", fmt.Errorf("YAML encode error: %s", err)
 		}
 	}
-
-	tmpFile, err := os.CreateTemp("", "wr_*.yml")
-	if err != nil {
-		return tea.Println(errStyle.Render(fmt.Sprintf("Failed to create temp file: %s", err)))
-	}
-	if _, err := io.WriteString(tmpFile, out.String()); err != nil {
-		return tea.Println(errStyle.Render(fmt.Sprintf("Failed to write temp file: %s", err)))
-	}
-	tmpFile.Close()
-	tmpPath := tmpFile.Name()
-
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = os.Getenv("VISUAL")
-	}
-	if editor == "" {
-		editor = "vi"
-	}
-
-	m.setBusy(true)
-	c := exec.Command(editor, tmpPath)
-	return tea.ExecProcess(c, func(err error) tea.Msg {
-		if err != nil {
-			return editorFinishedMsg{err: err}
-		}
-		content, readErr := os.ReadFile(tmpPath)
-		os.Remove(tmpPath)
-		return editorFinishedMsg{content: string(content), err: readErr}
-	})
+	return out.String(), nil
 }
 
 // Styles
@@ -377,6 +1059,7 @@ var (
 	echoStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a")).Bold(true)
 	dimStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#555555"))
 	errStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5555"))
+	warnStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a"))
 	successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#00ff00"))
 	helpStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#8197bf")).Bold(true)
 )
@@ -391,8 +1074,60 @@ func flattenEntries(t *Translation) []ParsedEntry {
 	return entries
 }
 
+var wordHeadingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a")).Bold(true).Underline(true)
+
 func renderEntries(word string, entries []ParsedEntry, start, end int) string {
-	wordStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a")).Bold(true).Underline(true)
+	var sb strings.Builder
+	if start == 0 {
+		sb.WriteString(wordHeadingStyle.Render(word) + "\n\n")
+	}
+	sb.WriteString(renderEntryBlocks(entries, start, end))
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// Renders the entries of t grouped under their section titles, keeping the
+// same numbering as flattenEntries. Only sections of kind are shown unless
+// kind is empty.
+func renderSections(word string, t *Translation, kind SectionKind) string {
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#8197bf")).Bold(true)
+
+	var sb strings.Builder
+	sb.WriteString(wordHeadingStyle.Render(word) + "\n")
+	entries := flattenEntries(t)
+	start, shown := 0, 0
+	for _, section := range t.Translations {
+		end := start + len(section.Entries)
+		if kind == "" || section.Kind == kind {
+			sb.WriteString("\n" + titleStyle.Render(section.Title) + "\n")
+			sb.WriteString(renderEntryBlocks(entries, start, end))
+			shown += end - start
+		}
+		start = end
+	}
+	if shown == 0 {
+		return dimStyle.Render(fmt.Sprintf("No %s entries.", kind))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// Renders the entries of the given part of speech, keeping their numbering.
+func renderEntriesWithPOS(word string, entries []ParsedEntry, pos PartOfSpeech) string {
+	var sb strings.Builder
+	sb.WriteString(wordHeadingStyle.Render(word) + "\n\n")
+	shown := 0
+	for i, entry := range entries {
+		if entryHasPOS(entry, pos) {
+			sb.WriteString(renderEntryBlocks(entries, i, i+1))
+			shown++
+		}
+	}
+	if shown == 0 {
+		return dimStyle.Render(fmt.Sprintf("No %s entries.", pos))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func renderEntryBlocks(entries []ParsedEntry, start, end int) string {
 	idxStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#555555")).Bold(true)
 	fromExStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#70b950"))
 	toExStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#c6b6ee")).Italic(true)
@@ -400,14 +1135,17 @@ func renderEntries(word string, entries []ParsedEntry, start, end int) string {
 	bar := barStyle.Render("│")
 
 	var sb strings.Builder
-	if start == 0 {
-		sb.WriteString(wordStyle.Render(word) + "\n\n")
-	}
-
 	for i := start; i < end; i++ {
 		entry := entries[i]
 		var block strings.Builder
-		block.WriteString(fmt.Sprintf("%s %s\n", idxStyle.Render(fmt.Sprintf("%d.", i+1)), entry.FromWord.ColorString()))
+		block.WriteString(fmt.Sprintf("%s %s", idxStyle.Render(fmt.Sprintf("%d.", i+1)), entry.FromWord.ColorString()))
+		if entry.Context != "" {
+			block.WriteString(" " + dimStyle.Render("("+entry.Context+")"))
+		}
+		if labels := entry.Labels.String(); labels != "" {
+			block.WriteString(" " + labelStyle.Render("["+labels+"]"))
+		}
+		block.WriteString("\n")
 		for _, tw := range entry.ToWords {
 			block.WriteString(fmt.Sprintf("   %s\n", tw.ColorString()))
 		}
@@ -424,7 +1162,7 @@ func renderEntries(word string, entries []ParsedEntry, start, end int) string {
 		}
 		sb.WriteString(fmt.Sprintf(" %s\n", renderFadeLine()))
 	}
-	return strings.TrimRight(sb.String(), "\n")
+	return sb.String()
 }
 
 func renderDecks(decks []Deck) string {
@@ -464,6 +1202,8 @@ func renderTemplates(templates []Template) string {
 	return sb.String()
 }
 
+var highlightRe = regexp.MustCompile(`\*\*(.+?)\*\*`)
+
 func parsePhrases(raw string) []Phrase {
 	var phrases []Phrase
 	for _, line := range strings.Split(raw, "\n") {
@@ -472,11 +1212,20 @@ func parsePhrases(raw string) []Phrase {
 			continue
 		}
 		line = strings.TrimPrefix(line, "- ")
-		// Strip ** markers for stored plain text
-		plain := strings.ReplaceAll(line, "**", "")
-		if parts := strings.SplitN(plain, " — ", 2); len(parts) == 2 {
-			phrases = append(phrases, Phrase{Source: parts[0], Target: parts[1]})
+		parts := strings.SplitN(line, " — ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		var highlights []string
+		for _, m := range highlightRe.FindAllStringSubmatch(parts[0], -1) {
+			highlights = append(highlights, m[1])
 		}
+		// Strip ** markers for stored plain text
+		phrases = append(phrases, Phrase{
+			Source:     strings.ReplaceAll(parts[0], "**", ""),
+			Target:     strings.ReplaceAll(parts[1], "**", ""),
+			Highlights: highlights,
+		})
 	}
 	return phrases
 }
@@ -497,6 +1246,112 @@ func renderPhrases(phrases []Phrase) string {
 	return strings.TrimRight(sb.String(), "\n")
 }
 
+func renderWarning(err error) string {
+	return warnStyle.Render("Warning: " + err.Error())
+}
+
+func renderSuggestions(suggestions []string) string {
+	idxStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#555555")).Bold(true)
+	wordStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+
+	var sb strings.Builder
+	sb.WriteString(helpStyle.Render("Did you mean:") + "\n")
+	for i, s := range suggestions {
+		sb.WriteString(fmt.Sprintf("  %s %s\n", idxStyle.Render(fmt.Sprintf("%d.", i+1)), wordStyle.Render(s)))
+	}
+	sb.WriteString(dimStyle.Render("  /try <n> to look one up"))
+	return sb.String()
+}
+
+func renderRestored(typed, restored string) string {
+	formStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a"))
+	wordStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff")).Bold(true)
+	return fmt.Sprintf("  %s → %s", formStyle.Render(typed), wordStyle.Render(restored))
+}
+
+func renderLemma(word string, lemma *LemmaCandidate) string {
+	formStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a"))
+	lemmaStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff")).Bold(true)
+	return fmt.Sprintf("  %s = %s%s",
+		formStyle.Render(word),
+		lemmaStyle.Render(lemma.Lemma),
+		dimStyle.Render(", "+lemma.Analysis),
+	)
+}
+
+func renderPairs(dict DictionaryProvider) string {
+	nameStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a")).Bold(true)
+	idSt := lipgloss.NewStyle().Foreground(lipgloss.Color("#555555"))
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+
+	var caps []string
+	for _, c := range []struct {
+		cap  Capability
+		name string
+	}{{CapConjugation, "conjugation"}, {CapExamples, "examples"}, {CapOffline, "offline"}} {
+		if dict.Capabilities().Has(c.cap) {
+			caps = append(caps, c.name)
+		}
+	}
+
+	pairs := dict.Pairs()
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i].Code < pairs[j].Code })
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s\n", nameStyle.Render(dict.Name()), idSt.Render(strings.Join(caps, ", "))))
+	for _, p := range pairs {
+		sb.WriteString(fmt.Sprintf("  %s %s\n", idSt.Render(p.Code), labelStyle.Render(p.From+" → "+p.To)))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func renderBooks(books []*book) string {
+	nameStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a")).Bold(true)
+	idSt := lipgloss.NewStyle().Foreground(lipgloss.Color("#555555"))
+
+	var sb strings.Builder
+	for _, b := range books {
+		sb.WriteString(fmt.Sprintf("%s %s\n", nameStyle.Render(b.Title), idSt.Render(fmt.Sprintf("%d sentences", len(b.Sentences)))))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func renderBookExamples(examples []bookExample) string {
+	fromStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#70b950"))
+	matchStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a")).Bold(true)
+	idxStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#555555")).Bold(true)
+
+	var sb strings.Builder
+	for i, ex := range examples {
+		sentence := strings.Replace(ex.Sentence, ex.Match, matchStyle.Render(ex.Match), 1)
+		sb.WriteString(fmt.Sprintf("  %s %s %s\n",
+			idxStyle.Render(fmt.Sprintf("%d.", i+1)),
+			fromStyle.Render(sentence),
+			dimStyle.Render("— "+ex.Book),
+		))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func renderMnemonic(mnemonic string) string {
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#8197bf")).Bold(true)
+	textStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+
+	var sb strings.Builder
+	for _, line := range strings.Split(mnemonic, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if label, rest, ok := strings.Cut(line, ":"); ok {
+			sb.WriteString(fmt.Sprintf("  %s %s\n", labelStyle.Render(label+":"), textStyle.Render(strings.TrimSpace(rest))))
+		} else {
+			sb.WriteString(fmt.Sprintf("  %s\n", textStyle.Render(line)))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
 func renderFadeLine() string {
 	// Base color #8197bf = rgb(129, 151, 191), fade toward black
 	const steps = 12
@@ -517,7 +1372,6 @@ func renderFadeLine() string {
 
 // Utilities
 
-
 func Map[T any, U any](input []T, fn func(T) U) []U {
 	result := make([]U, len(input))
 	for i, v := range input {
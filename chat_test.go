@@ -0,0 +1,398 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chatDriver runs a chatModel the way the Bubble Tea program would, but
+// synchronously: commands run as soon as they are emitted, output is
+// collected through the model's printer, and every other message is kept in
+// emitted until delivered.
+type chatDriver struct {
+	t       *testing.T
+	m       chatModel
+	out     []string
+	emitted []tea.Msg
+}
+
+func newChatDriver(t *testing.T, translations map[string]*Translation) *chatDriver {
+	t.Helper()
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	t.Setenv("OPENAI_API_KEY", "") // LLM commands fail fast instead of calling out
+	lookup := &wordLookup{dict: &fakeProvider{Translations: translations}}
+	d := &chatDriver{t: t, m: newChatModel(lookup, apiClients{})}
+	d.m.print = func(args ...any) tea.Cmd {
+		d.out = append(d.out, fmt.Sprint(args...))
+		// Like tea.Println, return a command: callers such as parseIndex
+		// signal errors with a non-nil one.
+		return func() tea.Msg { return nil }
+	}
+	return d
+}
+
+// Types line into the input and presses enter.
+func (d *chatDriver) typeLine(line string) {
+	d.m.textInput.SetValue(line)
+	d.send(tea.KeyMsg{Type: tea.KeyEnter})
+}
+
+func (d *chatDriver) send(msg tea.Msg) {
+	var cmd tea.Cmd
+	d.m, cmd = d.m.update(msg)
+	d.run(cmd)
+}
+
+// Delivers the emitted messages back to the model, as the program loop would,
+// until nothing more is emitted.
+func (d *chatDriver) settle() {
+	for len(d.emitted) > 0 {
+		msg := d.emitted[0]
+		d.emitted = d.emitted[1:]
+		d.send(msg)
+	}
+}
+
+func (d *chatDriver) run(cmd tea.Cmd) {
+	for _, msg := range runCmd(cmd) {
+		switch msg := msg.(type) {
+		case spinner.TickMsg, nil:
+		default:
+			d.emitted = append(d.emitted, msg)
+		}
+	}
+}
+
+// Returns and clears the output printed so far.
+func (d *chatDriver) output() string {
+	out := strings.Join(d.out, "\n")
+	d.out = nil
+	return out
+}
+
+func (d *chatDriver) expectOutput(want ...string) {
+	d.t.Helper()
+	out := d.output()
+	for _, w := range want {
+		if !strings.Contains(out, w) {
+			d.t.Errorf("output missing %q:\n%s", w, out)
+		}
+	}
+}
+
+func takeEmitted[T tea.Msg](d *chatDriver) (T, bool) {
+	for i, msg := range d.emitted {
+		if m, ok := msg.(T); ok {
+			d.emitted = append(d.emitted[:i], d.emitted[i+1:]...)
+			return m, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// A translation of word with principal entries perro1..perroN and the given
+// compound forms.
+func testTranslation(word string, principal int, compounds ...string) *Translation {
+	t := &Translation{Word: word, FromLang: "English", ToLang: "Spanish"}
+	section := TranslationSection{Title: "Principal Translations", Kind: SectionPrincipal}
+	for i := 1; i <= principal; i++ {
+		section.Entries = append(section.Entries, ParsedEntry{
+			FromWord: FromWord{Source: word, Grammar: "n", Tags: parseGrammar("n")},
+			ToWords:  []ToWord{{Meaning: fmt.Sprintf("perro%d", i), Grammar: "nm", Tags: parseGrammar("nm")}},
+			Context:  fmt.Sprintf("sense %d", i),
+		})
+	}
+	t.Translations = append(t.Translations, section)
+	if len(compounds) > 0 {
+		section := TranslationSection{Title: "Compound Forms", Kind: SectionCompound}
+		for _, c := range compounds {
+			section.Entries = append(section.Entries, ParsedEntry{
+				FromWord: FromWord{Source: c, Grammar: "n", Tags: parseGrammar("n")},
+				ToWords:  []ToWord{{Meaning: "perrito caliente", Grammar: "loc nom m", Tags: parseGrammar("loc nom m")}},
+			})
+		}
+		t.Translations = append(t.Translations, section)
+	}
+	return t
+}
+
+func TestChatLookupAndPaging(t *testing.T) {
+	d := newChatDriver(t, map[string]*Translation{"dog": testTranslation("dog", 5, "hot dog", "dog days")})
+
+	d.typeLine("dog")
+	if !d.m.busy {
+		t.Error("not busy while looking up")
+	}
+	d.typeLine("cat") // ignored while busy
+	if len(d.emitted) != 1 {
+		t.Fatalf("emitted %d messages, want just the lookup result", len(d.emitted))
+	}
+	d.settle()
+	d.expectOutput("> dog", "1. dog", "perro5", "Showing 5 of 7")
+	if d.m.busy {
+		t.Error("still busy after the result arrived")
+	}
+
+	d.typeLine("/more")
+	out := d.output()
+	if !strings.Contains(out, "6. hot dog") || !strings.Contains(out, "7. dog days") || strings.Contains(out, "perro1") {
+		t.Errorf("/more shows the wrong page:\n%s", out)
+	}
+	d.typeLine("/more")
+	d.expectOutput("No more results.")
+	d.typeLine("/all")
+	d.expectOutput("No more results.")
+
+	d.typeLine("/show compound")
+	out = d.output()
+	if !strings.Contains(out, "Compound Forms") || !strings.Contains(out, "6. hot dog") || strings.Contains(out, "perro1") {
+		t.Errorf("/show compound:\n%s", out)
+	}
+	d.typeLine("/show nonsense")
+	d.expectOutput("Usage: /show [principal|additional|compound|phrasal|other|all]")
+	d.typeLine("/pos verb")
+	d.expectOutput("No verb entries.")
+}
+
+func TestChatLookupFailure(t *testing.T) {
+	d := newChatDriver(t, nil)
+
+	d.typeLine("dgo")
+	d.settle()
+	d.expectOutput(`Error: no entry for "dgo"`)
+	if d.m.lastTranslation != nil {
+		t.Error("failed lookup replaced the last translation")
+	}
+	for _, cmd := range []string{"/more", "/all", "/show", "/pos noun"} {
+		d.typeLine(cmd)
+		d.expectOutput("No results to")
+	}
+	d.typeLine("/add 1")
+	d.expectOutput("No translation to add from. Look up a word first.")
+	d.typeLine("/bogus")
+	d.expectOutput("Unknown command: /bogus")
+}
+
+func TestChatIndexValidation(t *testing.T) {
+	d := newChatDriver(t, map[string]*Translation{"dog": testTranslation("dog", 3, "hot dog")})
+	d.typeLine("dog")
+	d.settle()
+	d.output()
+
+	cases := []struct{ input, want string }{
+		{"/add", "Usage: /add [index...]"},
+		{"/add 0", "Invalid index: 0"},
+		{"/add 5", "Invalid index: 5"},
+		{"/add x", `"x" is not a number`},
+		{"/phrases 9", "Invalid index: 9 (must be 1-4)"},
+		{"/mnemonic two", `"two" is not a number`},
+		{"/phrase 1", "Entry 1 is a principal translation, not a compound form or idiom."},
+		{"/phrase 5", "Invalid index: 5 (must be 1-4)"},
+		{"/cards 1", "No phrases available. Use /phrases <n> first."},
+		{"/pos", "Usage: /pos <noun|verb|"},
+	}
+	for _, c := range cases {
+		d.typeLine(c.input)
+		if out := d.output(); !strings.Contains(out, c.want) {
+			t.Errorf("%s: output %q, want %q", c.input, out, c.want)
+		}
+		if len(d.emitted) > 0 {
+			t.Errorf("%s: emitted %v for invalid input", c.input, d.emitted)
+			d.emitted = nil
+		}
+	}
+}
+
+func TestChatPhrasesToCards(t *testing.T) {
+	fake, _ := startFakeMochi(t)
+	d := newChatDriver(t, nil)
+	d.send(translateResultMsg{word: "dog", translation: testTranslation("dog", 2)})
+	d.expectOutput("1. dog", "perro2")
+
+	// Without an API key the request fails straight away.
+	d.typeLine("/phrases 1")
+	if _, ok := takeEmitted[phrasesResultMsg](d); !ok {
+		t.Fatal("/phrases did not request phrases")
+	}
+
+	d.send(phrasesResultMsg{err: fmt.Errorf("rate limited")})
+	d.expectOutput("Error: rate limited")
+
+	d.send(phrasesResultMsg{phrases: "- Mi **perro** duerme. — My dog sleeps.\n- El **perro** ladra. — The dog barks."})
+	d.expectOutput("Mi perro duerme.", "The dog barks.")
+	if len(d.m.lastPhrases) != 2 {
+		t.Fatalf("parsed %d phrases, want 2", len(d.m.lastPhrases))
+	}
+
+	d.typeLine("/cloze 3")
+	d.expectOutput("Invalid index: 3 (must be 1-2)")
+
+	d.typeLine("/cloze 2")
+	d.settle()
+	d.expectOutput("Successfully created 1 card(s).")
+	if cards := fake.Cards(); len(cards) != 1 || !strings.Contains(cards[0].Content, "El {{1::perro::The dog barks.}} ladra.") {
+		t.Errorf("created cards %+v", cards)
+	}
+
+	fake.failNext(500)
+	d.typeLine("/cards 1")
+	d.settle()
+	d.expectOutput("Error: failed to create card")
+}
+
+func TestChatAddOpensEditor(t *testing.T) {
+	d := newChatDriver(t, nil)
+	d.send(translateResultMsg{word: "dog", translation: testTranslation("dog", 2)})
+	d.output()
+
+	d.typeLine("/add 2")
+	if !d.m.busy || len(d.emitted) != 1 {
+		t.Fatalf("/add: busy %v, emitted %v; want the editor to be launched", d.m.busy, d.emitted)
+	}
+	d.emitted = nil
+
+	// The editor's result comes back as editorFinishedMsg.
+	d.send(editorFinishedMsg{err: fmt.Errorf("exit status 1")})
+	d.expectOutput("Editor error: exit status 1")
+	if d.m.busy {
+		t.Error("still busy after the editor failed")
+	}
+}
+
+func TestParsePhrasesHighlights(t *testing.T) {
+	raw := "Here you go:\n- Mi **perro** duerme. — My dog sleeps.\n- **Echar de menos** a **alguien**. — To miss someone.\n- no separator here\n"
+	phrases := parsePhrases(raw)
+	if len(phrases) != 2 {
+		t.Fatalf("parsed %d phrases, want 2: %+v", len(phrases), phrases)
+	}
+	if p := phrases[0]; p.Source != "Mi perro duerme." || p.Target != "My dog sleeps." || !reflect.DeepEqual(p.Highlights, []string{"perro"}) {
+		t.Errorf("first phrase %+v", p)
+	}
+	if got := phrases[1].Highlights; !reflect.DeepEqual(got, []string{"Echar de menos", "alguien"}) {
+		t.Errorf("second phrase highlights %q", got)
+	}
+}
+
+func TestChatTutorLookupWhileBusy(t *testing.T) {
+	d := newChatDriver(t, map[string]*Translation{"casa": testTranslation("casa", 1)})
+	d.typeLine("casa")
+	d.output()
+
+	d.send(lookupWordMsg{word: "perro"})
+	d.expectOutput(`Still busy; look up "perro"`)
+	if len(d.emitted) != 1 {
+		t.Errorf("emitted %d messages, want only the first lookup's result", len(d.emitted))
+	}
+	d.settle()
+	d.send(lookupWordMsg{word: "casa"})
+	d.settle()
+	d.expectOutput("> casa", "1. casa")
+}
+
+func TestChatBookSentences(t *testing.T) {
+	d := newChatDriver(t, nil)
+	d.send(translateResultMsg{word: "dog", translation: testTranslation("dog", 2)})
+	d.output()
+
+	// An English lookup searches the books for its Spanish translation.
+	d.typeLine("/sentences")
+	if msg, ok := takeEmitted[bookExamplesMsg](d); !ok || msg.word != "perro1" {
+		t.Errorf("/sentences searched for %+v, want perro1", msg)
+	}
+
+	d.send(bookExamplesMsg{word: "perro", examples: []bookExample{{Book: "Cuentos", Sentence: "El perro duerme.", Match: "perro"}}})
+	d.output()
+	d.typeLine("/cards 1")
+	d.expectOutput("Phrase 1 has no translation; use /cloze 1 instead.")
+	if len(d.emitted) > 0 {
+		t.Errorf("/cards emitted %v for an untranslated sentence", d.emitted)
+	}
+}
+
+func TestChatDeadKeysOffByDefault(t *testing.T) {
+	d := newChatDriver(t, nil)
+	typeRunes := func(s string) string {
+		d.m.textInput.SetValue("")
+		for _, r := range s {
+			// The returned commands only blink the cursor.
+			d.m, _ = d.m.update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		}
+		return d.m.textInput.Value()
+	}
+	if got := typeRunes("we're at o'clock??"); got != "we're at o'clock??" {
+		t.Errorf("typing English gave %q", got)
+	}
+
+	d.typeLine("/deadkeys")
+	d.expectOutput("are on")
+	if got := typeRunes("nin~o a'rbol"); got != "niño árbol" {
+		t.Errorf("typing with dead keys gave %q", got)
+	}
+}
+
+func TestChatDirectionCommands(t *testing.T) {
+	d := newChatDriver(t, nil)
+	d.m.lookup.reverse = &fakeProvider{}
+	for _, c := range []struct {
+		line string
+		want direction
+	}{
+		{"/from es", dirReverse},
+		{"/to es", dirForward},
+		{"/to EN", dirReverse},
+		{"/from en", dirForward},
+		{"/to fr", dirForward},
+		{"/from xyz", dirForward},
+		{"/to auto", dirAuto},
+	} {
+		d.typeLine(c.line)
+		if d.m.lookup.dir != c.want {
+			t.Errorf("%s: direction %v, want %v", c.line, d.m.lookup.dir, c.want)
+		}
+	}
+	d.expectOutput("Usage: /to <en|es|auto>", "Usage: /from <en|es|auto>")
+}
+
+func TestEntryPhraseSpanishSide(t *testing.T) {
+	forward := ParsedEntry{FromWord: FromWord{Source: "hot dog"}, ToWords: []ToWord{{Meaning: "perrito caliente"}, {Meaning: "pancho"}}}
+	p := entryPhrase(forward, "perro", false)
+	if p.Source != "perrito caliente" || p.Target != "hot dog" || len(p.Highlights) != 1 || p.Highlights[0] != "perrito caliente" {
+		t.Errorf("English → Spanish entry gave %+v", p)
+	}
+
+	reverse := ParsedEntry{FromWord: FromWord{Source: "casa de campo"}, ToWords: []ToWord{{Meaning: "country house"}, {Meaning: "cottage"}}}
+	p = entryPhrase(reverse, "casa", true)
+	if p.Source != "casa de campo" || p.Target != "country house; cottage" || len(p.Highlights) != 1 || p.Highlights[0] != "casa" {
+		t.Errorf("Spanish → English entry gave %+v", p)
+	}
+}
+
+func TestTutorFollowsDeadKeysSetting(t *testing.T) {
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	m := newModel(&wordLookup{dict: &fakeProvider{}}, apiClients{})
+	typeInTutor := func(s string) string {
+		m, _ = m.switchMode(modeTutor)
+		m.tutor.textInput.SetValue("")
+		for _, r := range s {
+			next, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			m = next.(model)
+		}
+		value := m.tutor.textInput.Value()
+		m, _ = m.switchMode(modeChat)
+		return value
+	}
+
+	if got := typeInTutor("we're at o'clock"); got != "we're at o'clock" {
+		t.Errorf("tutor rewrote English with dead keys off: %q", got)
+	}
+	m.chat.deadKeys = true // as /deadkeys does
+	if got := typeInTutor("nin~o"); got != "niño" {
+		t.Errorf("tutor ignored dead keys turned on in the chat: %q", got)
+	}
+}
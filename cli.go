@@ -0,0 +1,276 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const cliUsage = `Usage: ankibuilder [command]
+
+With no command, starts the interactive chat.
+
+Commands:
+  lookup <word> [--from en|es] [--json]          translate a word (language detected unless --from)
+  phrases <word> [--from en|es] [--sense n] [--fresh] [--json]
+                                                 generate example sentences for entry n (default 1)
+  add --from <file.yml|-> [--deck id]            create cards from /add-style YAML documents
+  decks [--json]                                 list Mochi decks
+`
+
+// cli runs the non-interactive subcommands. Output goes to out and errors to
+// errOut; lookup builds the dictionary only for commands that need one.
+type cli struct {
+	out    io.Writer
+	errOut io.Writer
+	lookup func() (*wordLookup, error)
+	api    apiClients
+}
+
+// errUsage marks errors caused by bad arguments, which exit with status 2.
+var errUsage = errors.New("usage")
+
+// Runs the subcommand in args and returns the process exit code.
+func (c *cli) run(args []string) int {
+	var err error
+	switch args[0] {
+	case "lookup":
+		err = c.lookupCmd(args[1:])
+	case "phrases":
+		err = c.phrasesCmd(args[1:])
+	case "add":
+		err = c.addCmd(args[1:])
+	case "decks":
+		err = c.decksCmd(args[1:])
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(c.out, cliUsage)
+		return 0
+	default:
+		err = fmt.Errorf("%w: unknown command %q", errUsage, args[0])
+	}
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		fmt.Fprintln(c.errOut, strings.TrimPrefix(err.Error(), "usage: "))
+		fmt.Fprint(c.errOut, cliUsage)
+		return 2
+	default:
+		fmt.Fprintln(c.errOut, errStyle.Render("Error: "+err.Error()))
+		return 1
+	}
+}
+
+func (c *cli) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.errOut)
+	return fs
+}
+
+// Parses args with flags allowed before and after the positional arguments,
+// so both "lookup --json dog" and "lookup dog --json" work.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("%w: %v", errUsage, err)
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func (c *cli) writeJSON(v any) error {
+	enc := json.NewEncoder(c.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// Parses a --from value: the language of the word, or "" to detect it.
+func parseFromLang(from string) (direction, error) {
+	switch strings.ToLower(from) {
+	case "":
+		return dirAuto, nil
+	case "en":
+		return dirForward, nil
+	case "es":
+		return dirReverse, nil
+	}
+	return dirAuto, fmt.Errorf("%w: --from must be en or es, not %q", errUsage, from)
+}
+
+// Looks up the word in args from the given language, or detecting it for
+// dirAuto, printing suggestions when there is no entry.
+func (c *cli) translate(args []string, dir direction) (lookupResult, string, error) {
+	word := strings.TrimSpace(strings.Join(args, " "))
+	if word == "" {
+		return lookupResult{}, "", fmt.Errorf("%w: missing word", errUsage)
+	}
+	l, err := c.lookup()
+	if err != nil {
+		return lookupResult{}, word, err
+	}
+	if dir != dirAuto {
+		if l.provider(dir) == nil {
+			return lookupResult{}, word, fmt.Errorf("no %s dictionary available", dir)
+		}
+		l = l.withDirection(dir)
+	}
+	res, err := l.Lookup(word)
+	if err != nil {
+		if suggestions := suggestionsFor(l.provider(res.dir), word, err); len(suggestions) > 0 {
+			fmt.Fprintln(c.errOut, "Did you mean: "+strings.Join(suggestions, ", "))
+		}
+		return res, word, err
+	}
+	return res, word, nil
+}
+
+type lookupOutput struct {
+	Word        string       `json:"word"`
+	Restored    string       `json:"restored,omitempty"`
+	Lemma       string       `json:"lemma,omitempty"`
+	Analysis    string       `json:"analysis,omitempty"`
+	Direction   string       `json:"direction"`
+	Translation *Translation `json:"translation"`
+}
+
+func (c *cli) lookupCmd(args []string) error {
+	fs := c.flagSet("lookup")
+	from := fs.String("from", "", "language of the word, `en` or es; detected if not given")
+	asJSON := fs.Bool("json", false, "print the translation as JSON")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	dir, err := parseFromLang(*from)
+	if err != nil {
+		return err
+	}
+	res, word, err := c.translate(args, dir)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		out := lookupOutput{Word: word, Restored: res.restored, Direction: res.dir.String(), Translation: res.translation}
+		if res.lemma != nil {
+			out.Lemma, out.Analysis = res.lemma.Lemma, res.lemma.Analysis
+		}
+		return c.writeJSON(out)
+	}
+	entries := flattenEntries(res.translation)
+	output := renderEntries(word, entries, 0, len(entries))
+	if res.lemma != nil {
+		output = renderLemma(cmp.Or(res.restored, word), res.lemma) + "\n" + output
+	}
+	if res.restored != "" {
+		output = renderRestored(word, res.restored) + "\n" + output
+	}
+	fmt.Fprintln(c.out, output)
+	return nil
+}
+
+func (c *cli) phrasesCmd(args []string) error {
+	fs := c.flagSet("phrases")
+	from := fs.String("from", "", "language of the word, `en` or es; detected if not given")
+	sense := fs.Int("sense", 1, "entry `n` of the lookup to write sentences for")
+	fresh := fs.Bool("fresh", false, "skip the LLM response cache")
+	asJSON := fs.Bool("json", false, "print the sentences as JSON")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	dir, err := parseFromLang(*from)
+	if err != nil {
+		return err
+	}
+	res, _, err := c.translate(args, dir)
+	if err != nil {
+		return err
+	}
+	entries := flattenEntries(res.translation)
+	if *sense < 1 || *sense > len(entries) {
+		return fmt.Errorf("%w: invalid sense: %d (must be 1-%d)", errUsage, *sense, len(entries))
+	}
+
+	raw, err := generatePhrases(c.api, entries[*sense-1], *fresh)
+	if err != nil {
+		return err
+	}
+	phrases := parsePhrases(raw)
+	if len(phrases) == 0 {
+		return fmt.Errorf("no sentences in the response")
+	}
+	if *asJSON {
+		return c.writeJSON(phrases)
+	}
+	fmt.Fprintln(c.out, renderPhrases(phrases))
+	return nil
+}
+
+func (c *cli) addCmd(args []string) error {
+	fs := c.flagSet("add")
+	from := fs.String("from", "", "YAML `file` of cards to create, or - for stdin")
+	deck := fs.String("deck", defaultDeckID, "Mochi deck `id` to add the cards to")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if *from == "" || len(args) > 0 {
+		return fmt.Errorf("%w: add takes --from <file.yml|->", errUsage)
+	}
+
+	var content []byte
+	if *from == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(*from)
+	}
+	if err != nil {
+		return err
+	}
+	count, storeErr, err := createCardsFromYAML(c.api, *deck, string(content))
+	if count > 0 {
+		fmt.Fprintf(c.out, "Created %d card(s).\n", count)
+	}
+	if storeErr != nil {
+		fmt.Fprintln(c.errOut, renderWarning(storeErr))
+	}
+	return err
+}
+
+func (c *cli) decksCmd(args []string) error {
+	fs := c.flagSet("decks")
+	asJSON := fs.Bool("json", false, "print the decks as JSON")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("%w: decks takes no arguments", errUsage)
+	}
+	decks, err := c.api.mochi().ListDecks()
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.writeJSON(decks)
+	}
+	fmt.Fprint(c.out, renderDecks(decks))
+	return nil
+}
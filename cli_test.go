@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runTestCLI(t *testing.T, translations map[string]*Translation, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	c := &cli{out: &out, errOut: &errOut, lookup: func() (*wordLookup, error) {
+		return &wordLookup{dict: &fakeProvider{Translations: translations}}, nil
+	}}
+	code = c.run(args)
+	return code, out.String(), errOut.String()
+}
+
+func TestCLILookup(t *testing.T) {
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	translations := map[string]*Translation{"dog": testTranslation("dog", 12)}
+
+	code, out, _ := runTestCLI(t, translations, "lookup", "dog")
+	if code != 0 || !strings.Contains(out, "1. dog") || !strings.Contains(out, "perro12") {
+		t.Errorf("lookup dog: exit %d, output:\n%s", code, out)
+	}
+
+	code, out, _ = runTestCLI(t, translations, "lookup", "--json", "dog")
+	var got lookupOutput
+	if err := json.Unmarshal([]byte(out), &got); err != nil || code != 0 {
+		t.Fatalf("lookup --json: exit %d, %v:\n%s", code, err, out)
+	}
+	if got.Word != "dog" || got.Direction != "English → Spanish" || len(flattenEntries(got.Translation)) != 12 {
+		t.Errorf("lookup --json gave %+v", got)
+	}
+
+	if code, _, errOut := runTestCLI(t, translations, "lookup", "dgo"); code != 1 || !strings.Contains(errOut, `no entry for "dgo"`) {
+		t.Errorf("lookup dgo: exit %d, stderr %q", code, errOut)
+	}
+}
+
+func TestCLIUsageErrors(t *testing.T) {
+	translations := map[string]*Translation{"dog": testTranslation("dog", 2)}
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"bogus"}, `unknown command "bogus"`},
+		{[]string{"lookup"}, "missing word"},
+		{[]string{"lookup", "--nope", "dog"}, "flag provided but not defined: -nope"},
+		{[]string{"phrases", "dog", "--sense", "3"}, "invalid sense: 3 (must be 1-2)"},
+		{[]string{"add"}, "add takes --from"},
+		{[]string{"decks", "extra"}, "decks takes no arguments"},
+	}
+	for _, c := range cases {
+		code, _, errOut := runTestCLI(t, translations, c.args...)
+		if code != 2 || !strings.Contains(errOut, c.want) {
+			t.Errorf("%v: exit %d, stderr %q; want 2 and %q", c.args, code, errOut, c.want)
+		}
+	}
+}
+
+func TestCLIAddAndDecks(t *testing.T) {
+	fake, _ := startFakeMochi(t)
+	path := filepath.Join(t.TempDir(), "cards.yml")
+	yml := "TargetLang: dog (n)\nSourceLang: el perro (nm)\n---\nTargetLang: cat (n)\nSourceLang: el gato (nm)\n"
+	if err := os.WriteFile(path, []byte(yml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, out, errOut := runTestCLI(t, nil, "add", "--from", path, "--deck", "drillDk1")
+	if code != 0 || !strings.Contains(out, "Created 4 card(s).") {
+		t.Fatalf("add: exit %d, stdout %q, stderr %q", code, out, errOut)
+	}
+	if cards := fake.Cards(); len(cards) != 4 || cards[3].DeckID != "drillDk1" {
+		t.Errorf("created cards %+v", cards)
+	}
+
+	code, out, _ = runTestCLI(t, nil, "decks", "--json")
+	var decks []Deck
+	if err := json.Unmarshal([]byte(out), &decks); err != nil || code != 0 || len(decks) != 2 {
+		t.Errorf("decks --json: exit %d, %v:\n%s", code, err, out)
+	}
+
+	fake.failNext(500)
+	if code, _, errOut := runTestCLI(t, nil, "decks"); code != 1 || !strings.Contains(errOut, "Error:") {
+		t.Errorf("decks with a failing server: exit %d, stderr %q", code, errOut)
+	}
+}
+
+func TestCLILookupFrom(t *testing.T) {
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	lookup := &wordLookup{
+		dict:    &fakeProvider{Translations: map[string]*Translation{"son": testTranslation("son", 1)}},
+		reverse: &fakeProvider{Translations: map[string]*Translation{"son": testTranslation("son", 2)}},
+	}
+	run := func(args ...string) (int, string, string) {
+		var out, errOut bytes.Buffer
+		c := &cli{out: &out, errOut: &errOut, lookup: func() (*wordLookup, error) { return lookup, nil }}
+		return c.run(args), out.String(), errOut.String()
+	}
+
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"lookup", "son", "--json"}, "English → Spanish"},
+		{[]string{"lookup", "--from", "en", "son", "--json"}, "English → Spanish"},
+		{[]string{"lookup", "son", "--from=es", "--json"}, "Spanish → English"},
+	} {
+		code, out, errOut := run(c.args...)
+		var got lookupOutput
+		if err := json.Unmarshal([]byte(out), &got); err != nil || code != 0 {
+			t.Fatalf("%v: exit %d, %v, stderr %q", c.args, code, err, errOut)
+		}
+		if got.Direction != c.want {
+			t.Errorf("%v: direction %q, want %q", c.args, got.Direction, c.want)
+		}
+	}
+
+	if code, _, errOut := run("lookup", "--from", "fr", "son"); code != 2 || !strings.Contains(errOut, `--from must be en or es, not "fr"`) {
+		t.Errorf("--from fr: exit %d, stderr %q", code, errOut)
+	}
+	lookup.reverse = nil
+	if code, _, errOut := run("lookup", "--from", "es", "son"); code != 1 || !strings.Contains(errOut, "no Spanish → English dictionary available") {
+		t.Errorf("--from es without a reverse dictionary: exit %d, stderr %q", code, errOut)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+const CONJUGATION_PATH = "conj/%sverbs.aspx?v=%s"
+
+// WordReference italicises irregular forms in its conjugation tables.
+const conjIrregularSelector = "i"
+
+// Conjugation is a verb's full conjugation table from WordReference.
+type Conjugation struct {
+	Verb       string
+	Lang       string
+	Gerund     string
+	Participle string
+	Tenses     []ConjTense
+}
+
+// ConjTense is one tense of one mood, e.g. indicativo / pretérito.
+type ConjTense struct {
+	Mood  string
+	Name  string
+	Forms []ConjForm
+}
+
+type ConjForm struct {
+	Person    string
+	Form      string
+	Irregular bool
+}
+
+// Conjugator is implemented by providers that can conjugate verbs.
+type Conjugator interface {
+	Conjugate(verb string) (*Conjugation, error)
+}
+
+// Finds a Conjugator among p and the providers it wraps.
+func conjugatorOf(p DictionaryProvider) (Conjugator, bool) {
+	return providerAs[Conjugator](p)
+}
+
+// The language whose verbs the dictionary conjugates: the non-English side
+// of the pair.
+func (wr *WordReference) conjLang() string {
+	if strings.HasPrefix(wr.DictCode, "en") {
+		return wr.DictCode[2:]
+	}
+	return wr.DictCode[:2]
+}
+
+func (wr *WordReference) Conjugate(verb string) (*Conjugation, error) {
+	lang := wr.conjLang()
+	switch lang {
+	case "es", "fr", "it":
+	default:
+		return nil, fmt.Errorf("conjugation is not available for %s", lang)
+	}
+	u := wr.baseURL + fmt.Sprintf(CONJUGATION_PATH, lang, url.QueryEscape(verb))
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", wr.UserAgent)
+
+	resp, err := wr.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	conj := parseConjugation(doc)
+	if len(conj.Tenses) == 0 {
+		return nil, errors.New("no conjugation found for " + verb)
+	}
+	conj.Verb = verb
+	conj.Lang = lang
+	return conj, nil
+}
+
+// Parses the conjugation tables of a WordReference verb page. Each mood is
+// a div.aa headed by an h4, holding one table.neoConj per tense.
+func parseConjugation(doc *goquery.Document) *Conjugation {
+	conj := &Conjugation{}
+	doc.Find("#cheader td").Each(func(_ int, td *goquery.Selection) {
+		text := strings.TrimSpace(td.Text())
+		switch {
+		case strings.HasPrefix(text, "gerundio:"):
+			conj.Gerund = strings.TrimSpace(strings.TrimPrefix(text, "gerundio:"))
+		case strings.HasPrefix(text, "participio:"):
+			conj.Participle = strings.TrimSpace(strings.TrimPrefix(text, "participio:"))
+		}
+	})
+
+	doc.Find("div.aa").Each(func(_ int, div *goquery.Selection) {
+		mood := strings.TrimSpace(div.Find("h4").First().Text())
+		div.Find("table.neoConj").Each(func(_ int, tbl *goquery.Selection) {
+			tense := ConjTense{
+				Mood: mood,
+				Name: strings.TrimSpace(tbl.Find("tr").First().Find("th").First().Text()),
+			}
+			tbl.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+				person := tr.Find("th[scope=row]")
+				td := tr.Find("td").First()
+				if person.Length() == 0 || td.Length() == 0 {
+					return
+				}
+				tense.Forms = append(tense.Forms, ConjForm{
+					Person:    strings.TrimSpace(person.Text()),
+					Form:      strings.Join(strings.Fields(td.Text()), " "),
+					Irregular: td.Find(conjIrregularSelector).Length() > 0,
+				})
+			})
+			if tense.Name != "" && len(tense.Forms) > 0 {
+				conj.Tenses = append(conj.Tenses, tense)
+			}
+		})
+	})
+	return conj
+}
+
+// Renders the selected tenses (all if none are selected) as tables laid
+// out side by side within width.
+func renderConjugation(conj *Conjugation, selected []int, width int) string {
+	headStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a")).Bold(true)
+	personStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#555555")).PaddingRight(1)
+	formStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+	irregStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ff8855")).Bold(true)
+	barStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#8197bf"))
+
+	if len(selected) == 0 {
+		for i := range conj.Tenses {
+			selected = append(selected, i)
+		}
+	}
+
+	var tables []string
+	for _, i := range selected {
+		tense := conj.Tenses[i]
+		t := table.New().
+			Border(lipgloss.RoundedBorder()).
+			BorderStyle(barStyle).
+			BorderColumn(false).
+			Headers(fmt.Sprintf("%d. %s", i+1, tense.Name), "").
+			StyleFunc(func(row, col int) lipgloss.Style {
+				if row == table.HeaderRow {
+					return headStyle
+				}
+				if col == 0 {
+					return personStyle
+				}
+				if tense.Forms[row].Irregular {
+					return irregStyle
+				}
+				return formStyle
+			})
+		for _, f := range tense.Forms {
+			t.Row(f.Person, f.Form)
+		}
+		tables = append(tables, dimStyle.Render(tense.Mood)+"\n"+t.Render())
+	}
+
+	var sb strings.Builder
+	sb.WriteString(headStyle.Underline(true).Render(conj.Verb))
+	if conj.Gerund != "" || conj.Participle != "" {
+		sb.WriteString(dimStyle.Render(fmt.Sprintf("  gerundio: %s  participio: %s", conj.Gerund, conj.Participle)))
+	}
+	sb.WriteString("\n")
+
+	// Pack tables into rows that fit the terminal width.
+	var row []string
+	rowWidth := 0
+	for _, t := range tables {
+		w := lipgloss.Width(t) + 1
+		if len(row) > 0 && width > 0 && rowWidth+w > width {
+			sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, row...) + "\n")
+			row, rowWidth = nil, 0
+		}
+		row = append(row, lipgloss.NewStyle().PaddingRight(1).Render(t))
+		rowWidth += w
+	}
+	if len(row) > 0 {
+		sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, row...))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Conjugation pages under testdata/wordref, named conj_<lang>_<verb>.html.
+// Like the translation fixtures they were written by hand, not captured, and
+// are replaced with downloaded pages by
+//
+//	go test -run TestParseConjugation -refresh -update
+var conjFixtures = []struct {
+	lang, verb string
+}{
+	{"es", "tener"}, // irregular forms, two moods, participles in the header
+}
+
+func TestParseConjugation(t *testing.T) {
+	for _, fx := range conjFixtures {
+		name := "conj_" + fx.lang + "_" + fx.verb
+		t.Run(name, func(t *testing.T) {
+			htmlPath := filepath.Join("testdata", "wordref", name+".html")
+			goldenPath := filepath.Join("testdata", "wordref", name+".golden.json")
+
+			if *refreshFixtures {
+				url := WR_URL + fmt.Sprintf(CONJUGATION_PATH, fx.lang, fx.verb)
+				if err := downloadFixture(url, htmlPath); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			f, err := os.Open(htmlPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			doc, err := goquery.NewDocumentFromReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := json.MarshalIndent(parseConjugation(doc), "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("%s (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("parsed %s differs from %s (run with -update to accept):\n%s", htmlPath, goldenPath, lineDiff(string(want), string(got)))
+			}
+		})
+	}
+}
+
+func TestChatConjInvalidTenseKeepsSelection(t *testing.T) {
+	d := newChatDriver(t, nil)
+	conj := &Conjugation{Verb: "tener", Tenses: []ConjTense{
+		{Mood: "indicativo", Name: "presente", Forms: []ConjForm{{Person: "yo", Form: "tengo"}}},
+		{Mood: "indicativo", Name: "pretérito", Forms: []ConjForm{{Person: "yo", Form: "tuve"}}},
+	}}
+	d.send(conjugationResultMsg{conj: conj, selected: []int{2}})
+	if len(d.m.conjTenses) != 1 || d.m.conjTenses[0] != 1 {
+		t.Fatalf("selected tenses %v, want [1]", d.m.conjTenses)
+	}
+
+	d.typeLine("/conj 1 7")
+	d.settle()
+	d.expectOutput("Invalid tense: 7 (must be 1-2)")
+	if len(d.m.conjTenses) != 1 || d.m.conjTenses[0] != 1 {
+		t.Errorf("an invalid tense changed the selection to %v", d.m.conjTenses)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"flag"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+var recordCassettes = flag.Bool("record", false, "record cassettes against the real services (needs MOCHI_KEY and OPENAI_API_KEY)")
+
+// Returns API clients that route the test's HTTP traffic through
+// testdata/cassettes/<name>.json. With -record the real services are called
+// and the cassette rewritten; otherwise every request must have been
+// recorded, and every recording must be used.
+//
+// The cassettes were written by hand from the services' documented request
+// and response shapes, not recorded; run `just record` to replace them with
+// real recordings.
+func useCassette(t *testing.T, name string) apiClients {
+	t.Helper()
+	path := filepath.Join("testdata", "cassettes", name+".json")
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	t.Setenv("WORDREFERENCE_URL", "")
+	t.Setenv("MOCHI_URL", "")
+	t.Setenv("OPENAI_BASE_URL", "")
+	t.Setenv("OPENAI_MONTHLY_BUDGET", "")
+
+	if *recordCassettes {
+		c := recordCassette(path, nil)
+		t.Cleanup(func() {
+			if err := c.Save(); err != nil {
+				t.Error(err)
+			}
+		})
+		return apiClients{transport: c}
+	}
+
+	t.Setenv("MOCHI_KEY", "test-key")
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	c, err := loadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if unused := c.Unused(); len(unused) > 0 && !t.Failed() {
+			t.Errorf("recorded requests never made: %v", unused)
+		}
+	})
+	return apiClients{transport: c}
+}
+
+// Runs cmd and any commands it batches, returning the messages they produce.
+func runCmd(cmd tea.Cmd) []tea.Msg {
+	if cmd == nil {
+		return nil
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		var msgs []tea.Msg
+		for _, c := range batch {
+			msgs = append(msgs, runCmd(c)...)
+		}
+		return msgs
+	}
+	return []tea.Msg{msg}
+}
+
+func findMsg[T tea.Msg](t *testing.T, msgs []tea.Msg) T {
+	t.Helper()
+	for _, msg := range msgs {
+		if m, ok := msg.(T); ok {
+			return m
+		}
+	}
+	var zero T
+	t.Fatalf("no %T among %v", zero, msgs)
+	return zero
+}
+
+func lookupFromCassette(t *testing.T, api apiClients, word string) (chatModel, translateResultMsg) {
+	t.Helper()
+	wr, err := NewWordReference("en", "es", api.transport)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lookup := &wordLookup{dict: wr, words: loadWordList("es")}
+	res := translateCmd(lookup, word)().(translateResultMsg)
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	m := newChatModel(lookup, api)
+	m, _ = m.update(res)
+	return m, res
+}
+
+func TestLookupAddCreatesCards(t *testing.T) {
+	api := useCassette(t, "lookup_add")
+
+	m, res := lookupFromCassette(t, api, "dog")
+	if got := len(flattenEntries(res.translation)); got != 6 {
+		t.Fatalf("got %d entries for dog, want 6", got)
+	}
+
+	content, err := m.addTemplates([]string{"1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"TargetLang: dog (n)", "SourceLang: el perro (nm)", "Context: canine"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("template missing %q:\n%s", want, content)
+		}
+	}
+
+	m, cmd := m.update(editorFinishedMsg{content: content})
+	result := findMsg[addCardResultMsg](t, runCmd(cmd))
+	if result.err != nil || result.count != 2 {
+		t.Fatalf("created %d cards, err %v; want 2 cards", result.count, result.err)
+	}
+	if vs, err := openVocabStore(); err != nil || !vs.Has("perro") {
+		t.Errorf("created card's word not remembered as known (err %v)", err)
+	}
+}
+
+func TestPhrasesCreateClozeCard(t *testing.T) {
+	api := useCassette(t, "phrases_cloze")
+
+	m, res := lookupFromCassette(t, api, "dog")
+	phrases := phrasesCmd(api, flattenEntries(res.translation)[0], true)().(phrasesResultMsg)
+	if phrases.err != nil {
+		t.Fatal(phrases.err)
+	}
+	m, _ = m.update(phrases)
+	if len(m.lastPhrases) != 2 {
+		t.Fatalf("got %d phrases, want 2", len(m.lastPhrases))
+	}
+
+	result := createPhraseCardsCmd(api, m.lastPhrases[:1], cardKindCloze)().(addCardResultMsg)
+	if result.err != nil || result.count != 1 {
+		t.Fatalf("created %d cards, err %v; want 1 card", result.count, result.err)
+	}
+	if usage := llmUsage.session; len(usage) == 0 || usage[len(usage)-1].Purpose != purposePhrases {
+		t.Error("phrases request not recorded in the usage ledger")
+	}
+}
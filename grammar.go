@@ -0,0 +1,332 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// PartOfSpeech is the word class of a dictionary entry.
+type PartOfSpeech string
+
+const (
+	POSNoun         PartOfSpeech = "noun"
+	POSVerb         PartOfSpeech = "verb"
+	POSAdjective    PartOfSpeech = "adjective"
+	POSAdverb       PartOfSpeech = "adverb"
+	POSPronoun      PartOfSpeech = "pronoun"
+	POSPreposition  PartOfSpeech = "preposition"
+	POSConjunction  PartOfSpeech = "conjunction"
+	POSInterjection PartOfSpeech = "interjection"
+	POSArticle      PartOfSpeech = "article"
+	POSExpression   PartOfSpeech = "expression"
+)
+
+var partsOfSpeech = []PartOfSpeech{POSNoun, POSVerb, POSAdjective, POSAdverb, POSPronoun,
+	POSPreposition, POSConjunction, POSInterjection, POSArticle, POSExpression}
+
+type Gender string
+
+const (
+	GenderMasculine Gender = "m"
+	GenderFeminine  Gender = "f"
+	GenderNeuter    Gender = "n"
+	GenderCommon    Gender = "mf" // either, e.g. "estudiante nmf" or "nm, nf"
+)
+
+type GrammarNumber string
+
+const (
+	NumberSingular GrammarNumber = "singular"
+	NumberPlural   GrammarNumber = "plural"
+)
+
+type Transitivity string
+
+const (
+	Transitive   Transitivity = "transitive"
+	Intransitive Transitivity = "intransitive"
+)
+
+// GrammarTags is the structured form of a WordReference grammar abbreviation
+// such as "nm", "nfpl", "vtr", "v prnl" or "loc adj". Unknown fields are left
+// empty.
+type GrammarTags struct {
+	POS          PartOfSpeech  `json:"pos,omitempty"`
+	Gender       Gender        `json:"gender,omitempty"`
+	Number       GrammarNumber `json:"number,omitempty"`
+	Transitivity Transitivity  `json:"transitivity,omitempty"`
+	Pronominal   bool          `json:"pronominal,omitempty"`
+	Locution     bool          `json:"locution,omitempty"` // multi-word "loc" entries
+}
+
+// Nouns written with gender and number glued on: English-side "nm", "nfpl",
+// Portuguese "sm", German "Nn".
+var nounAbbrevRe = regexp.MustCompile(`^(n|s)(m|f|mf|n)?(pl|sing)?$`)
+
+// Abbreviations that name a part of speech on their own, in the labels used
+// by the WordReference dictionaries and by Wiktionary.
+var posAbbrevs = map[string]PartOfSpeech{
+	"noun": POSNoun, "nom": POSNoun, "sust": POSNoun, "nome": POSNoun,
+	"v": POSVerb, "verb": POSVerb, "verbo": POSVerb,
+	"adj": POSAdjective, "adjective": POSAdjective, "agg": POSAdjective,
+	"adv": POSAdverb, "adverb": POSAdverb, "avv": POSAdverb,
+	"pron": POSPronoun, "pronoun": POSPronoun,
+	"prep": POSPreposition, "preposition": POSPreposition,
+	"conj": POSConjunction, "conjunction": POSConjunction, "cong": POSConjunction,
+	"interj": POSInterjection, "interjection": POSInterjection, "inter": POSInterjection,
+	"art": POSArticle, "article": POSArticle, "det": POSArticle,
+	"expr": POSExpression, "phrase": POSExpression, "proverb": POSExpression,
+}
+
+// Parses a raw grammar abbreviation. It understands the conventions of all
+// WordReference language pairs, which share most abbreviations and differ
+// mainly in how pronominal verbs are marked (prnl, pron, rif, vr, vp).
+func parseGrammar(raw string) GrammarTags {
+	var g GrammarTags
+	addGender := func(gen Gender) {
+		if g.Gender != "" && g.Gender != gen {
+			gen = GenderCommon
+		}
+		g.Gender = gen
+	}
+	setPOS := func(pos PartOfSpeech) {
+		// The first class wins: "n as adj" is a noun used attributively.
+		if g.POS == "" {
+			g.POS = pos
+		}
+	}
+
+	tokens := strings.FieldsFunc(strings.ToLower(raw), func(r rune) bool {
+		return r == ' ' || r == ',' || r == '+' || r == '/' || r == '.' || r == '(' || r == ')'
+	})
+	for _, tok := range tokens {
+		switch tok {
+		case "loc", "locución", "locuzione":
+			g.Locution = true
+			continue
+		case "m", "masc":
+			addGender(GenderMasculine)
+			continue
+		case "f", "fem":
+			addGender(GenderFeminine)
+			continue
+		case "mf":
+			addGender(GenderCommon)
+			continue
+		case "pl", "plural":
+			g.Number = NumberPlural
+			continue
+		case "sing", "singular":
+			g.Number = NumberSingular
+			continue
+		case "tr", "trans", "transitive":
+			g.Transitivity = Transitive
+			continue
+		case "intr", "intransitive":
+			g.Transitivity = Intransitive
+			continue
+		case "prnl", "rif", "refl", "reflexive", "pronominal":
+			g.Pronominal = true
+			continue
+		case "pron":
+			// "v pron" is a French pronominal verb, bare "pron" a pronoun.
+			if g.POS == POSVerb {
+				g.Pronominal = true
+				continue
+			}
+		case "phrasal":
+			setPOS(POSVerb)
+			continue
+		}
+
+		if m := nounAbbrevRe.FindStringSubmatch(tok); m != nil && (m[1] == "n" || m[2] != "") {
+			setPOS(POSNoun)
+			if m[2] != "" {
+				addGender(Gender(m[2]))
+			}
+			if m[3] == "pl" {
+				g.Number = NumberPlural
+			}
+			continue
+		}
+		if pos, ok := posAbbrevs[tok]; ok {
+			setPOS(pos)
+			continue
+		}
+		// Verbs with transitivity glued on: "vtr", "vt", "vi", "vintr",
+		// "vprnl", "vr", "vp".
+		if strings.HasPrefix(tok, "v") && len(tok) <= 6 {
+			switch tok[1:] {
+			case "tr", "t":
+				setPOS(POSVerb)
+				g.Transitivity = Transitive
+			case "i", "intr":
+				setPOS(POSVerb)
+				g.Transitivity = Intransitive
+			case "prnl", "r", "p", "pr", "rif":
+				setPOS(POSVerb)
+				g.Pronominal = true
+			}
+		}
+	}
+	if g.Locution && g.POS == "" {
+		g.POS = POSExpression
+	}
+	return g
+}
+
+// Definite articles by language and gender, singular then plural.
+var definiteArticles = map[string]map[Gender][2]string{
+	"es": {GenderMasculine: {"el", "los"}, GenderFeminine: {"la", "las"}},
+	"fr": {GenderMasculine: {"le", "les"}, GenderFeminine: {"la", "les"}},
+	"it": {GenderMasculine: {"il", "i"}, GenderFeminine: {"la", "le"}},
+	"pt": {GenderMasculine: {"o", "os"}, GenderFeminine: {"a", "as"}},
+	"ca": {GenderMasculine: {"el", "els"}, GenderFeminine: {"la", "les"}},
+	"de": {GenderMasculine: {"der", "die"}, GenderFeminine: {"die", "die"}, GenderNeuter: {"das", "die"}},
+}
+
+// Reports whether word is one of the definite articles withArticle adds
+// for lang, including the "el/la" of common-gender nouns.
+func isArticle(lang, word string) bool {
+	for _, forms := range definiteArticles[languageCode(lang)] {
+		if word == forms[0] || word == forms[1] {
+			return true
+		}
+	}
+	m, f, ok := strings.Cut(word, "/")
+	return ok && isArticle(lang, m) && isArticle(lang, f)
+}
+
+// Language labels as they appear in Translation.FromLang/ToLang.
+var languageCodes = map[string]string{
+	"english": "en", "inglés": "en",
+	"spanish": "es", "español": "es",
+	"french": "fr", "français": "fr", "francés": "fr",
+	"italian": "it", "italiano": "it",
+	"portuguese": "pt", "português": "pt", "portugués": "pt",
+	"catalan": "ca", "català": "ca", "catalán": "ca",
+	"german": "de", "deutsch": "de", "alemán": "de",
+}
+
+func languageCode(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if code, ok := languageCodes[lang]; ok {
+		return code
+	}
+	return lang
+}
+
+// Feminine Spanish nouns starting with a stressed "a" take "el" in the
+// singular: el agua, el hacha.
+var stressedAFeminine = map[string]bool{
+	"agua": true, "águila": true, "alma": true, "arma": true, "área": true, "aula": true,
+	"ala": true, "ancla": true, "arpa": true, "asma": true, "hambre": true, "hacha": true,
+	"hada": true, "habla": true, "alba": true, "ave": true, "aya": true, "álgebra": true,
+}
+
+// Prefixes a noun with its definite article, e.g. "el perro", "la casa",
+// "el/la estudiante" or "el perro, la perra". Words that are not nouns, or
+// whose language or gender is unknown, are returned unchanged.
+func withArticle(lang, word string, g GrammarTags) string {
+	articles, ok := definiteArticles[languageCode(lang)]
+	if !ok || g.POS != POSNoun || word == "" {
+		return word
+	}
+	code := languageCode(lang)
+	article := func(gen Gender, w string) string {
+		forms, ok := articles[gen]
+		if !ok {
+			return ""
+		}
+		if g.Number == NumberPlural {
+			return forms[1]
+		}
+		lw := strings.ToLower(w)
+		switch {
+		case code == "es" && gen == GenderFeminine && stressedAFeminine[lw]:
+			return "el"
+		case (code == "fr" || code == "it" || code == "ca") && startsWithVowel(lw):
+			return "l'"
+		}
+		return forms[0]
+	}
+	join := func(art, w string) string {
+		if art == "" {
+			return w
+		}
+		if strings.HasSuffix(art, "'") {
+			return art + w
+		}
+		return art + " " + w
+	}
+
+	if g.Gender == GenderCommon {
+		// "perro, perra" with "nm, nf" pairs each form with its own article.
+		if parts := strings.Split(word, ", "); len(parts) == 2 {
+			return join(article(GenderMasculine, parts[0]), parts[0]) + ", " +
+				join(article(GenderFeminine, parts[1]), parts[1])
+		}
+		m, f := article(GenderMasculine, word), article(GenderFeminine, word)
+		if m == f {
+			return join(m, word)
+		}
+		return m + "/" + f + " " + word
+	}
+	return join(article(g.Gender, word), word)
+}
+
+func startsWithVowel(w string) bool {
+	for _, r := range w {
+		return strings.ContainsRune("aeiouhàâäéèêëíìîïóòôöúùûü", r)
+	}
+	return false
+}
+
+// Color for words of each gender; nouns without a gender keep the default.
+func genderStyle(g Gender) lipgloss.Style {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+	switch g {
+	case GenderMasculine:
+		return style.Foreground(lipgloss.Color("#8fbfdc"))
+	case GenderFeminine:
+		return style.Foreground(lipgloss.Color("#e5a3c6"))
+	case GenderNeuter:
+		return style.Foreground(lipgloss.Color("#99ad6a"))
+	case GenderCommon:
+		return style.Foreground(lipgloss.Color("#c6b6ee"))
+	}
+	return style
+}
+
+// Reports whether the entry's source word or any of its translations is the
+// given part of speech.
+func entryHasPOS(entry ParsedEntry, pos PartOfSpeech) bool {
+	if entry.FromWord.Tags.POS == pos {
+		return true
+	}
+	for _, tw := range entry.ToWords {
+		if tw.Tags.POS == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// Finds a part of speech by name or common abbreviation, e.g. "adj".
+func findPOS(name string) (PartOfSpeech, bool) {
+	name = strings.ToLower(name)
+	for _, pos := range partsOfSpeech {
+		if string(pos) == name {
+			return pos, true
+		}
+	}
+	if pos, ok := posAbbrevs[name]; ok {
+		return pos, true
+	}
+	if name == "n" {
+		return POSNoun, true
+	}
+	return "", false
+}
@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+func TestParseGrammar(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want GrammarTags
+	}{
+		{"nm", GrammarTags{POS: POSNoun, Gender: GenderMasculine}},
+		{"nfpl", GrammarTags{POS: POSNoun, Gender: GenderFeminine, Number: NumberPlural}},
+		{"nm, nf", GrammarTags{POS: POSNoun, Gender: GenderCommon}},
+		{"n", GrammarTags{POS: POSNoun}},
+		{"sm", GrammarTags{POS: POSNoun, Gender: GenderMasculine}},
+		{"vtr", GrammarTags{POS: POSVerb, Transitivity: Transitive}},
+		{"vi", GrammarTags{POS: POSVerb, Transitivity: Intransitive}},
+		{"v prnl", GrammarTags{POS: POSVerb, Pronominal: true}},
+		{"v pron", GrammarTags{POS: POSVerb, Pronominal: true}},
+		{"pron", GrammarTags{POS: POSPronoun}},
+		{"loc adj", GrammarTags{POS: POSAdjective, Locution: true}},
+		{"loc nom m", GrammarTags{POS: POSNoun, Gender: GenderMasculine, Locution: true}},
+		{"loc", GrammarTags{POS: POSExpression, Locution: true}},
+		{"n as adj", GrammarTags{POS: POSNoun}},
+		{"", GrammarTags{}},
+	}
+	for _, c := range cases {
+		if got := parseGrammar(c.raw); got != c.want {
+			t.Errorf("parseGrammar(%q) = %+v, want %+v", c.raw, got, c.want)
+		}
+	}
+}
+
+func TestWithArticle(t *testing.T) {
+	cases := []struct {
+		lang, word, grammar, want string
+	}{
+		{"Spanish", "casa", "nf", "la casa"},
+		{"Spanish", "perros", "nmpl", "los perros"},
+		{"Spanish", "agua", "nf", "el agua"},
+		{"Spanish", "estudiante", "nmf", "el/la estudiante"},
+		{"Spanish", "perro, perra", "nm, nf", "el perro, la perra"},
+		{"Spanish", "correr", "vi", "correr"},
+		{"French", "arbre", "nm", "l'arbre"},
+		{"German", "Haus", "nn", "das Haus"},
+		{"English", "dog", "n", "dog"},
+	}
+	for _, c := range cases {
+		if got := withArticle(c.lang, c.word, parseGrammar(c.grammar)); got != c.want {
+			t.Errorf("withArticle(%q, %q, %q) = %q, want %q", c.lang, c.word, c.grammar, got, c.want)
+		}
+	}
+}
+
+func TestKnownWord(t *testing.T) {
+	cases := map[string]string{
+		"la casa (nf)":            "casa",
+		"El Perro":                "perro",
+		"los perros\nmore":        "perros",
+		"el/la estudiante (nmf)":  "estudiante",
+		"correr":                  "correr",
+		"las":                     "las",
+		"die Zeit":                "die zeit",    // German articles are not Spanish
+		"les enfants":             "les enfants", // nor French ones
+		"as cosas":                "as cosas",    // nor Portuguese ones
+		"tener en cuenta (loc v)": "tener en cuenta",
+	}
+	for in, want := range cases {
+		if got := knownWord(in); got != want {
+			t.Errorf("knownWord(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
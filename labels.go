@@ -0,0 +1,112 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Region labels used by WordReference (both page languages) and Wiktionary
+// sense tags.
+var regionLabels = map[string]bool{
+	"aml": true, "amc": true, "ams": true, "amer": true, "esp": true, "méx": true, "mex": true, "mx": true,
+	"arg": true, "rp": true, "cs": true, "col": true, "ven": true, "chi": true, "ch": true, "cu": true,
+	"per": true, "ur": true, "uy": true, "bol": true, "ecu": true, "ec": true, "cr": true, "pan": true,
+	"pr": true, "rd": true, "gua": true, "hond": true, "nic": true, "par": true, "py": true, "sv": true,
+	"uk": true, "us": true, "ca": true, "au": true, "nz": true, "ie": true, "irl": true, "sco": true,
+	"safr": true, "ind": true, "carib": true,
+	"spain": true, "latin america": true, "latin-america": true, "mexico": true, "argentina": true,
+	"rioplatense": true, "caribbean": true, "central america": true, "south america": true,
+	"colombia": true, "venezuela": true, "chile": true, "cuba": true, "peru": true, "uruguay": true,
+}
+
+// Register and usage labels.
+var usageLabels = map[string]bool{
+	"informal": true, "formal": true, "coloquial": true, "colloquial": true, "coloq": true,
+	"slang": true, "jerga": true, "vulgar": true, "vulg": true, "offensive": true, "ofensivo": true,
+	"pejorative": true, "peyorativo": true, "pey": true, "derogatory": true,
+	"figurative": true, "figurativo": true, "figurado": true, "fig": true, "literal": true,
+	"literary": true, "literario": true, "dated": true, "anticuado": true, "old-fashioned": true,
+	"archaic": true, "arcaico": true, "humorous": true, "humorístico": true, "euphemism": true,
+	"eufemismo": true, "euphemistic": true, "technical": true, "técnico": true, "rare": true,
+	"poco usado": true, "obsolete": true, "childish": true, "infantil": true, "ironic": true, "irónico": true,
+}
+
+var parenGroupRe = regexp.MustCompile(`\(([^()]*)\)`)
+
+// Labels are the register and regional markers of an entry or translation,
+// e.g. "informal" and "AmL".
+type Labels struct {
+	Regions []string `json:"regions,omitempty"`
+	Usage   []string `json:"usage,omitempty"`
+}
+
+func (l Labels) clone() Labels {
+	return Labels{Regions: slices.Clone(l.Regions), Usage: slices.Clone(l.Usage)}
+}
+
+func (l Labels) String() string {
+	return strings.Join(append(append([]string{}, l.Usage...), l.Regions...), ", ")
+}
+
+// Adds label to l if it is a known region or usage label.
+func (l *Labels) add(label string) bool {
+	key := strings.ToLower(strings.Trim(label, " .[]"))
+	switch {
+	case regionLabels[key]:
+		l.Regions = append(l.Regions, strings.Trim(label, " []"))
+	case usageLabels[key]:
+		l.Usage = append(l.Usage, strings.Trim(label, " []"))
+	default:
+		return false
+	}
+	return true
+}
+
+// Classifies the comma separated items of every parenthesized group in text.
+// Items that are not labels make up the returned context; text outside
+// parentheses is context too.
+func splitLabels(text string) (string, Labels) {
+	var labels Labels
+	var context []string
+	rest := parenGroupRe.ReplaceAllStringFunc(text, func(group string) string {
+		var kept []string
+		for _, item := range strings.FieldsFunc(group[1:len(group)-1], func(r rune) bool { return r == ',' || r == ';' }) {
+			if item = strings.TrimSpace(item); item != "" && !labels.add(item) {
+				kept = append(kept, item)
+			}
+		}
+		if len(kept) > 0 {
+			context = append(context, strings.Join(kept, ", "))
+		}
+		return " "
+	})
+	if rest = strings.TrimSpace(strings.Join(strings.Fields(rest), " ")); rest != "" {
+		if !labels.add(rest) {
+			context = append(context, rest)
+		}
+	}
+	return strings.Join(context, "; "), labels
+}
+
+// Removes trailing label groups from a translation, as in "carro (AmL)".
+func trimLabels(meaning string) (string, Labels) {
+	var labels Labels
+	for {
+		groups := parenGroupRe.FindAllStringIndex(meaning, -1)
+		if len(groups) == 0 {
+			return meaning, labels
+		}
+		loc := groups[len(groups)-1]
+		if strings.TrimSpace(meaning[loc[1]:]) != "" {
+			return meaning, labels
+		}
+		ctx, found := splitLabels(meaning[loc[0]:loc[1]])
+		if ctx != "" || (len(found.Regions) == 0 && len(found.Usage) == 0) {
+			return meaning, labels
+		}
+		labels.Regions = append(labels.Regions, found.Regions...)
+		labels.Usage = append(labels.Usage, found.Usage...)
+		meaning = strings.TrimSpace(meaning[:loc[0]])
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+//go:embed lemmas/*.tsv
+var lemmaData embed.FS
+
+// Lemmatizer maps inflected forms to candidate dictionary headwords using a
+// rules file of exact irregular forms and suffix replacements.
+type Lemmatizer struct {
+	exact    map[string][]LemmaCandidate
+	suffixes []suffixRule // longest suffix first
+}
+
+type suffixRule struct {
+	suffix      string
+	replacement string
+	analysis    string
+}
+
+// LemmaCandidate is a possible headword for an inflected form.
+type LemmaCandidate struct {
+	Lemma    string
+	Analysis string // e.g. "1pl preterite"
+}
+
+func (c LemmaCandidate) String() string {
+	return fmt.Sprintf("%s, %s", c.Lemma, c.Analysis)
+}
+
+// Loads the rules for lang. A file named lemmas-<lang>.tsv in the data
+// directory replaces the built-in rules.
+func NewLemmatizer(lang string) (*Lemmatizer, error) {
+	var r io.ReadCloser
+	if path, err := dataPath("lemmas-" + lang + ".tsv"); err == nil {
+		if f, err := os.Open(path); err == nil {
+			r = f
+		}
+	}
+	if r == nil {
+		f, err := lemmaData.Open("lemmas/" + lang + ".tsv")
+		if err != nil {
+			return nil, fmt.Errorf("no lemmatization rules for %s", lang)
+		}
+		r = f
+	}
+	defer r.Close()
+	return parseLemmaRules(r)
+}
+
+func parseLemmaRules(r io.Reader) (*Lemmatizer, error) {
+	l := &Lemmatizer{exact: map[string][]LemmaCandidate{}}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if len(cols) != 3 {
+			return nil, fmt.Errorf("line %d: expected 3 tab-separated columns", lineNo)
+		}
+		form, lemma, analysis := cols[0], cols[1], cols[2]
+		if suffix, ok := strings.CutPrefix(form, "-"); ok {
+			l.suffixes = append(l.suffixes, suffixRule{
+				suffix:      suffix,
+				replacement: strings.TrimPrefix(lemma, "-"),
+				analysis:    analysis,
+			})
+			continue
+		}
+		for _, lm := range strings.Split(lemma, "/") {
+			l.exact[form] = append(l.exact[form], LemmaCandidate{Lemma: lm, Analysis: analysis})
+		}
+	}
+	sort.SliceStable(l.suffixes, func(i, j int) bool {
+		return len(l.suffixes[i].suffix) > len(l.suffixes[j].suffix)
+	})
+	return l, scanner.Err()
+}
+
+// Candidates returns possible lemmas for word, irregular forms first and
+// then suffix rules from the longest suffix down. The word itself is never
+// returned.
+func (l *Lemmatizer) Candidates(word string) []LemmaCandidate {
+	word = strings.ToLower(strings.TrimSpace(word))
+	var out []LemmaCandidate
+	seen := map[string]bool{word: true}
+	add := func(c LemmaCandidate) {
+		if !seen[c.Lemma] && len([]rune(c.Lemma)) > 1 {
+			seen[c.Lemma] = true
+			out = append(out, c)
+		}
+	}
+	for _, c := range l.exact[word] {
+		add(c)
+	}
+	for _, rule := range l.suffixes {
+		if stem, ok := strings.CutSuffix(word, rule.suffix); ok && stem != "" {
+			add(LemmaCandidate{Lemma: stem + rule.replacement, Analysis: rule.analysis})
+		}
+	}
+	return out
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Maximum number of extra lookups, lemmas and accent-restored spellings,
+// tried when a word has no entry.
+const maxMissLookups = 6
+
+// direction selects which way a lookup translates.
+type direction int
+
+const (
+	dirAuto    direction = iota // detect from the word
+	dirForward                  // English → Spanish
+	dirReverse                  // Spanish → English
+)
+
+func (d direction) String() string {
+	switch d {
+	case dirForward:
+		return "English → Spanish"
+	case dirReverse:
+		return "Spanish → English"
+	}
+	return "auto"
+}
+
+// wordLookup resolves typed words to dictionary entries, trying the word as
+// typed and, for Spanish input, its lemmas and accent-restored spellings.
+// Spanish input is sent to the reverse dictionary when one is available, and
+// in auto mode so is any word the English → Spanish dictionary lacks.
+type wordLookup struct {
+	dict    DictionaryProvider // nil when only the reverse direction is available
+	reverse DictionaryProvider // may be nil
+	lemmas  *Lemmatizer        // may be nil
+	words   []string           // word list for diacritic restoration
+	dir     direction
+}
+
+// lookupResult is a successful lookup and how the typed word was resolved.
+type lookupResult struct {
+	translation *Translation
+	dir         direction       // direction used, never dirAuto
+	restored    string          // accent-restored spelling, if one was used
+	lemma       *LemmaCandidate // lemma, if the word was inflected
+}
+
+// Reports whether word looks Spanish because it has Spanish-only characters.
+// Many Spanish words are also English ones ("he", "once", "son"), so a
+// word without them is only tried as Spanish when it was last found as
+// Spanish or English finds nothing.
+func looksSpanish(word string) bool {
+	return strings.ContainsAny(strings.ToLower(word), "ñáéíóúü¿¡")
+}
+
+// Returns a copy of l that always translates in dir.
+func (l *wordLookup) withDirection(dir direction) *wordLookup {
+	c := *l
+	c.dir = dir
+	return &c
+}
+
+// Returns the direction for word, detecting it when the direction is auto.
+func (l *wordLookup) directionFor(word string) direction {
+	if l.reverse == nil {
+		return dirForward
+	}
+	if l.dir != dirAuto {
+		return l.dir
+	}
+	if l.dict == nil {
+		return dirReverse
+	}
+	if looksSpanish(word) {
+		return dirReverse
+	}
+	// Words such as "sin" or "red" are both English and Spanish, so neither
+	// the Spanish word list nor the letters can tell; the direction the word
+	// was last found in can.
+	if vs, err := openVocabStore(); err == nil && vs.LookupLang(word) == "es" {
+		return dirReverse
+	}
+	return dirForward
+}
+
+// Returns the provider for dir, or nil if that direction is unavailable.
+func (l *wordLookup) provider(dir direction) DictionaryProvider {
+	if dir == dirReverse {
+		return l.reverse
+	}
+	return l.dict
+}
+
+// Returns the English → Spanish provider, or the reverse one when offline.
+func (l *wordLookup) primary() DictionaryProvider {
+	if l.dict != nil {
+		return l.dict
+	}
+	return l.reverse
+}
+
+func (l *wordLookup) Lookup(word string) (lookupResult, error) {
+	dir := l.directionFor(word)
+	res, err := l.lookupIn(dir, word)
+	if err != nil && l.dir == dirAuto && dir == dirForward && l.reverse != nil {
+		if rres, rerr := l.lookupIn(dirReverse, word); rerr == nil {
+			return rres, nil
+		}
+	}
+	return res, err
+}
+
+func (l *wordLookup) lookupIn(dir direction, word string) (lookupResult, error) {
+	dict := l.provider(dir)
+	if dict == nil {
+		return lookupResult{dir: dir}, fmt.Errorf("no %s dictionary available", dir)
+	}
+
+	translation, err := dict.Lookup(word)
+	if err == nil && len(flattenEntries(translation)) > 0 {
+		return lookupResult{translation: translation, dir: dir}, nil
+	}
+	// Lemmas and accents are Spanish features, only tried for Spanish input.
+	if dir != dirReverse {
+		return lookupResult{translation: translation, dir: dir}, err
+	}
+	for _, c := range l.missCandidates(dict, word) {
+		if t, cerr := dict.Lookup(c.word); cerr == nil && len(flattenEntries(t)) > 0 {
+			return lookupResult{translation: t, dir: dir, restored: c.restored, lemma: c.lemma}, nil
+		}
+	}
+	return lookupResult{translation: translation, dir: dir}, err
+}
+
+// missCandidate is a spelling tried when the typed word has no entry.
+type missCandidate struct {
+	word     string
+	restored string          // accent-restored spelling it came from, if any
+	lemma    *LemmaCandidate // set when word is a lemma
+}
+
+// Lists the spellings to try for a word with no entry: its lemmas, then
+// accent-restored spellings and their lemmas, without repeats and at most
+// maxMissLookups of them, since each one costs a dictionary lookup.
+func (l *wordLookup) missCandidates(dict DictionaryProvider, word string) []missCandidate {
+	seen := map[string]bool{strings.ToLower(word): true}
+	var out []missCandidate
+	add := func(c missCandidate) bool {
+		if !seen[c.word] {
+			seen[c.word] = true
+			out = append(out, c)
+		}
+		return len(out) < maxMissLookups
+	}
+	addLemmas := func(w, restored string) bool {
+		if l.lemmas == nil {
+			return true
+		}
+		for _, c := range l.lemmas.Candidates(w) {
+			if !add(missCandidate{word: c.Lemma, restored: restored, lemma: &c}) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if !addLemmas(word, "") {
+		return out
+	}
+	words := append(append([]string{}, l.words...), localWords(dict, word)...)
+	for _, r := range restoreDiacritics(word, words) {
+		if !add(missCandidate{word: r, restored: r}) || !addLemmas(r, r) {
+			return out
+		}
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// countingProvider records the words looked up through it.
+type countingProvider struct {
+	fakeProvider
+	looked []string
+}
+
+func (cp *countingProvider) Lookup(word string) (*Translation, error) {
+	cp.looked = append(cp.looked, word)
+	return cp.fakeProvider.Lookup(word)
+}
+
+func TestLookupMissCandidates(t *testing.T) {
+	lemmas, err := parseLemmaRules(strings.NewReader("-amos\t-ar\t1pl present\n-o\t-ar\t1sg present\n-os\t-o\tpl\n-s\t-\tpl\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+
+	reverse := &countingProvider{fakeProvider: fakeProvider{Translations: map[string]*Translation{
+		"hablar": testTranslation("hablar", 1),
+		"niño":   testTranslation("niño", 1),
+	}}}
+	l := &wordLookup{dict: &fakeProvider{}, reverse: reverse, lemmas: lemmas, words: []string{"niño", "niños"}, dir: dirReverse}
+
+	res, err := l.Lookup("hablamos")
+	if err != nil || res.lemma == nil || res.lemma.Lemma != "hablar" {
+		t.Errorf("hablamos gave %+v, %v; want the lemma hablar", res, err)
+	}
+
+	res, err = l.Lookup("ninos")
+	if err != nil || res.restored != "niños" || res.lemma == nil || res.lemma.Lemma != "niño" {
+		t.Errorf("ninos gave %+v, %v; want niño by way of niños", res, err)
+	}
+
+	// Each accented spelling brings its own lemmas; a miss must stop early.
+	l.words = []string{"xyzámos", "xýzamos", "xyzamós", "xÿzamos", "xyźamos"}
+	if got := l.missCandidates(reverse, "xyzamos"); len(got) != maxMissLookups {
+		t.Errorf("xyzamos has %d candidates, want the cap of %d", len(got), maxMissLookups)
+	}
+	reverse.looked = nil
+	if _, err := l.Lookup("xyzamos"); err == nil {
+		t.Fatal("xyzamos was found")
+	}
+	if len(reverse.looked) > 1+maxMissLookups {
+		t.Errorf("a miss cost %d lookups: %q", len(reverse.looked), reverse.looked)
+	}
+}
+
+func TestLookupEnglishSkipsLemmas(t *testing.T) {
+	lemmas, err := parseLemmaRules(strings.NewReader("-s\t-\tpl\n-o\t-ar\t1sg present\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dict := &countingProvider{fakeProvider: fakeProvider{Translations: map[string]*Translation{"run": testTranslation("run", 1)}}}
+	l := &wordLookup{dict: dict, lemmas: lemmas, words: []string{"rúns"}}
+
+	if _, err := l.Lookup("runs"); err == nil {
+		t.Error("runs was resolved through the Spanish lemmatizer")
+	}
+	if len(dict.looked) != 1 {
+		t.Errorf("English miss looked up %q, want only the typed word", dict.looked)
+	}
+}
+
+func TestLookupDirection(t *testing.T) {
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	dict := &countingProvider{fakeProvider: fakeProvider{Translations: map[string]*Translation{
+		"son": testTranslation("son", 1),
+	}}}
+	reverse := &countingProvider{fakeProvider: fakeProvider{Translations: map[string]*Translation{
+		"son":   testTranslation("son", 1),
+		"perro": testTranslation("perro", 1),
+		"niño":  testTranslation("niño", 1),
+	}}}
+	l := &wordLookup{dict: dict, reverse: reverse}
+
+	cases := []struct {
+		word string
+		want direction
+	}{
+		{"son", dirForward},   // an English word first
+		{"perro", dirReverse}, // no English entry
+		{"niño", dirReverse},  // Spanish-only letters
+	}
+	for _, c := range cases {
+		dict.looked, reverse.looked = nil, nil
+		res, err := l.Lookup(c.word)
+		if err != nil || res.dir != c.want {
+			t.Errorf("%s: dir %v, err %v; want %v", c.word, res.dir, err, c.want)
+		}
+	}
+	if len(dict.looked) != 0 {
+		t.Errorf("niño was looked up as English: %q", dict.looked)
+	}
+
+	if res, err := l.withDirection(dirForward).Lookup("perro"); err == nil || res.dir != dirForward {
+		t.Errorf("a fixed direction fell back to %v", res.dir)
+	}
+
+	// Once "son" has been found as Spanish, it is looked up as Spanish.
+	if msg := translateCmd(l.withDirection(dirReverse), "son")().(translateResultMsg); msg.err != nil {
+		t.Fatal(msg.err)
+	}
+	dict.looked = nil
+	if res, err := l.Lookup("son"); err != nil || res.dir != dirReverse || len(dict.looked) != 0 {
+		t.Errorf("son after a Spanish lookup: dir %v, err %v, English lookups %q", res.dir, err, dict.looked)
+	}
+}
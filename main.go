@@ -2,22 +2,98 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 func main() {
-	wr, err := NewWordReference("en", "es")
+	transport, saveCassette, err := cassetteFromEnv()
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	api := apiClients{transport: transport}
+	if err := fakeMochiFromEnv(); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+
+	if len(os.Args) > 1 {
+		c := &cli{out: os.Stdout, errOut: os.Stderr, api: api, lookup: func() (*wordLookup, error) {
+			return newWordLookup(api)
+		}}
+		code := c.run(os.Args[1:])
+		if err := saveCassette(); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving cassette:", err)
+		}
+		os.Exit(code)
+	}
+
+	lookup, err := newWordLookup(api)
 	if err != nil {
 		fmt.Println("Error:", err)
 		os.Exit(1)
 	}
 
-	m := newModel(wr)
+	// The terminal belongs to the UI, so log output goes to a file.
+	if path, err := dataPath("ankibuilder.log"); err == nil {
+		if f, err := tea.LogToFile(path, ""); err == nil {
+			defer f.Close()
+		}
+	}
+
+	m := newModel(lookup, api)
 	p := tea.NewProgram(m)
-	if _, err := p.Run(); err != nil {
+	_, err = p.Run()
+	if saveErr := saveCassette(); saveErr != nil {
+		fmt.Println("Error saving cassette:", saveErr)
+	}
+	if err != nil {
 		fmt.Println("Error running program:", err)
 		os.Exit(1)
 	}
 }
+
+// Builds the English → Spanish lookup shared by the chat and the CLI.
+func newWordLookup(api apiClients) (*wordLookup, error) {
+	wk, err := wiktionaryFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	wr, wrErr := NewWordReference("en", "es", api.transport)
+	if wrErr != nil {
+		// The offline extract can still serve Spanish → English.
+		wr = nil
+	}
+
+	lemmas, err := NewLemmatizer("es")
+	if err != nil {
+		return nil, err
+	}
+	lookup := &wordLookup{lemmas: lemmas, words: loadWordList("es")}
+	if wr != nil {
+		if lookup.dict, err = loadProvider(wr, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// WordReference also serves the opposite direction for Spanish input,
+	// the only direction the Wiktionary extract covers.
+	var rev *WordReference
+	if wr != nil {
+		if rev, err = wr.Reversed(); err != nil {
+			log.Printf("no Spanish → English WordReference dictionary: %v", err)
+		}
+	}
+	if rev != nil || wk != nil {
+		if lookup.reverse, err = loadProvider(rev, wk); err != nil {
+			return nil, err
+		}
+	}
+	if lookup.dict == nil && lookup.reverse == nil {
+		return nil, wrErr
+	}
+	return lookup, nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// minedWord is a candidate word found in mined text, with the first sentence
+// it appeared in.
+type minedWord struct {
+	Word     string
+	Count    int
+	Sentence string
+}
+
+// Common Spanish function words that are never worth a card.
+var spanishStopwords = map[string]bool{
+	"a": true, "al": true, "algo": true, "con": true, "como": true, "de": true, "del": true,
+	"el": true, "ella": true, "en": true, "es": true, "esa": true, "ese": true, "eso": true,
+	"esta": true, "este": true, "esto": true, "la": true, "las": true, "le": true, "les": true,
+	"lo": true, "los": true, "me": true, "mi": true, "muy": true, "más": true, "no": true,
+	"nos": true, "o": true, "para": true, "pero": true, "por": true, "que": true, "qué": true,
+	"se": true, "si": true, "sí": true, "su": true, "sus": true, "te": true, "tu": true,
+	"tú": true, "un": true, "una": true, "uno": true, "y": true, "ya": true, "yo": true,
+}
+
+var (
+	srtTimestampRe = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}[,.]\d{3}\s*-->`)
+	srtIndexRe     = regexp.MustCompile(`^\d+$`)
+	markupRe       = regexp.MustCompile(`<[^>]+>|\{\\[^}]*\}`)
+	sentenceEndRe  = regexp.MustCompile(`([.!?…]+["»”]?)\s+`)
+)
+
+// Converts SubRip subtitles to plain text, one cue per line.
+func stripSRT(raw string) string {
+	var cues []string
+	var cue []string
+	flush := func() {
+		if len(cue) > 0 {
+			cues = append(cues, strings.Join(cue, " "))
+			cue = nil
+		}
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(raw, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+		switch {
+		case line == "":
+			flush()
+		case srtIndexRe.MatchString(line) && len(cue) == 0:
+		case srtTimestampRe.MatchString(line):
+		default:
+			cue = append(cue, markupRe.ReplaceAllString(line, ""))
+		}
+	}
+	flush()
+	return strings.Join(cues, "\n")
+}
+
+// Splits text into sentences on terminal punctuation and line breaks.
+func splitSentences(text string) []string {
+	var sentences []string
+	for _, line := range strings.Split(text, "\n") {
+		line = sentenceEndRe.ReplaceAllString(line, "$1\n")
+		for _, s := range strings.Split(line, "\n") {
+			if s = strings.TrimSpace(s); s != "" {
+				sentences = append(sentences, s)
+			}
+		}
+	}
+	return sentences
+}
+
+// Splits a sentence into lowercase words, dropping punctuation and numbers.
+func tokenize(sentence string) []string {
+	fields := strings.FieldsFunc(sentence, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return Map(fields, strings.ToLower)
+}
+
+// Ranks the words of text by frequency, skipping stopwords and anything
+// known reports as already learned.
+func mineText(text string, known func(string) bool) []minedWord {
+	byWord := map[string]*minedWord{}
+	var order []*minedWord
+	for _, sentence := range splitSentences(text) {
+		for _, w := range tokenize(sentence) {
+			if len([]rune(w)) < 2 || spanishStopwords[w] {
+				continue
+			}
+			if mw, ok := byWord[w]; ok {
+				mw.Count++
+				continue
+			}
+			if known(w) {
+				continue
+			}
+			mw := &minedWord{Word: w, Count: 1, Sentence: sentence}
+			byWord[w] = mw
+			order = append(order, mw)
+		}
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return order[i].Count > order[j].Count
+	})
+	return Map(order, func(mw *minedWord) minedWord { return *mw })
+}
+
+// Reads a file to mine, converting subtitles to plain text.
+func readMineSource(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".srt") {
+		return stripSRT(string(data)), nil
+	}
+	return string(data), nil
+}
+
+// Returns the headwords already on cards in the deck.
+func deckWords(mc *MochiClient, deckID string) (map[string]bool, error) {
+	cards, err := mc.ListCardsInDeck(deckID)
+	if err != nil {
+		return nil, err
+	}
+	words := map[string]bool{}
+	for _, card := range cards {
+		for _, id := range []string{fwdTargetLangFieldID, revTargetLangFieldID, fwdSourceLangFieldID} {
+			if f, ok := card.Fields[id]; ok {
+				for _, line := range strings.Split(f.Value, "\n") {
+					if w := knownWord(line); w != "" {
+						words[w] = true
+					}
+				}
+			}
+		}
+	}
+	return words, nil
+}
+
+func renderMined(words []minedWord, limit int) string {
+	idxStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#555555")).Bold(true)
+	wordStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+	countStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#8197bf"))
+	sentStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#555555")).Italic(true)
+
+	var sb strings.Builder
+	for i, mw := range words {
+		if i >= limit {
+			sb.WriteString(dimStyle.Render(fmt.Sprintf("  … %d more", len(words)-limit)) + "\n")
+			break
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s %s %s\n",
+			idxStyle.Render(fmt.Sprintf("%3d.", i+1)),
+			wordStyle.Render(mw.Word),
+			countStyle.Render(fmt.Sprintf("×%d", mw.Count)),
+			sentStyle.Render(truncate(mw.Sentence, 60)),
+		))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStripSRT(t *testing.T) {
+	raw := "\ufeff1\r\n00:00:01,000 --> 00:00:03,500\r\n<i>¿Dónde está</i>\r\nel perro?\r\n\r\n" +
+		"2\n00:00:04.000 --> 00:00:05.000\n{\\an8}15 perros.\n"
+	want := "¿Dónde está el perro?\n15 perros."
+	if got := stripSRT(raw); got != want {
+		t.Errorf("stripSRT gave %q, want %q", got, want)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	got := tokenize("¡Hola, Señor! ¿Tienes 3 años? —Sí, l'amour.")
+	want := []string{"hola", "señor", "tienes", "años", "sí", "l", "amour"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenize gave %q, want %q", got, want)
+	}
+}
+
+func TestMineText(t *testing.T) {
+	text := "El perro come. El gato duerme y el perro ladra.\nLa casa es grande, muy grande."
+	known := func(w string) bool { return w == "gato" }
+	got := mineText(text, known)
+
+	words := Map(got, func(mw minedWord) string { return mw.Word })
+	want := []string{"perro", "grande", "come", "duerme", "ladra", "casa"}
+	if !reflect.DeepEqual(words, want) {
+		t.Fatalf("mined %q, want %q", words, want)
+	}
+	if got[0].Count != 2 || got[0].Sentence != "El perro come." {
+		t.Errorf("perro mined as %+v, want two uses and its first sentence", got[0])
+	}
+}
@@ -6,85 +6,161 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const defaultMochiURL = "https://app.mochi.cards/api/"
+
 type MochiClient struct {
-	client *http.Client
-	key    string
+	client  *http.Client
+	key     string
+	baseURL string
 }
 
-func NewMochiClient(key string) *MochiClient {
+// NewMochiClient talks to the Mochi API, or to MOCHI_URL if it is set,
+// through transport (http.DefaultTransport if nil).
+func NewMochiClient(key string, transport http.RoundTripper) *MochiClient {
+	baseURL := defaultMochiURL
+	if u := os.Getenv("MOCHI_URL"); u != "" {
+		baseURL = strings.TrimSuffix(u, "/") + "/"
+	}
 	return &MochiClient{
-		client: &http.Client{},
-		key:    key,
+		client:  newHTTPClient(transport),
+		key:     key,
+		baseURL: baseURL,
 	}
 }
 
 func (mc *MochiClient) ListDecks() ([]Deck, error) {
-	decks := Pagination[Deck]{}
-	if err := mc.getJSON("https://app.mochi.cards/api/decks", &decks); err != nil {
-		return nil, err
-	}
-	return decks.Docs, nil
+	return listAll[Deck](mc, "decks")
 }
 
-func (mc *MochiClient) postJSON(path string, payload any, into any) error {
-	req, err := http.NewRequest("POST", path, nil)
-	if err != nil {
-		return err
+// MochiError is a non-2xx response from the Mochi API.
+type MochiError struct {
+	Status int
+	Body   string
+}
+
+func (e *MochiError) Error() string {
+	return fmt.Sprintf("mochi: %s: %s", http.StatusText(e.Status), e.Body)
+}
+
+// Mochi allows one request at a time per account and answers 429 when
+// requests overlap, so those are retried after a short wait.
+const mochiRetries = 3
+
+func (mc *MochiClient) do(method, path string, payload any, into any) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return err
+		}
 	}
-	buf := &bytes.Buffer{}
-	enc := json.NewEncoder(buf)
-	if err := enc.Encode(payload); err != nil {
-		return err
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(method, path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if payload != nil {
+			req.Header.Add("Content-Type", "application/json")
+		}
+		req.SetBasicAuth(mc.key, "")
+		resp, err := mc.client.Do(req)
+		if err != nil {
+			return err
+		}
+		bodyText, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < mochiRetries {
+			time.Sleep(retryAfter(resp, attempt))
+			continue
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return &MochiError{Status: resp.StatusCode, Body: string(bodyText)}
+		}
+		if into == nil || len(bodyText) == 0 {
+			return nil
+		}
+		if err := json.Unmarshal(bodyText, into); err != nil {
+			return fmt.Errorf("mochi: invalid response from %s %s: %w", method, path, err)
+		}
+		return nil
 	}
-	req.Body = io.NopCloser(bytes.NewReader(buf.Bytes()))
+}
 
-	req.Header.Add("Content-Type", "application/json")
-	req.SetBasicAuth(mc.key, "")
-	resp, err := mc.client.Do(req)
-	if err != nil {
-		return err
+// Waits as long as the Retry-After header asks, or backs off linearly.
+func retryAfter(resp *http.Response, attempt int) time.Duration {
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(secs) * time.Second
 	}
-	defer resp.Body.Close()
+	return time.Duration(attempt+1) * 500 * time.Millisecond
+}
 
-	bodyText, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("failed: %s", string(bodyText))
-	}
-	return json.Unmarshal(bodyText, into)
+func (mc *MochiClient) postJSON(path string, payload any, into any) error {
+	return mc.do("POST", path, payload, into)
 }
 
 func (mc *MochiClient) getJSON(path string, into any) error {
-	req, err := http.NewRequest("GET", path, nil)
-	if err != nil {
-		return err
-	}
-	req.SetBasicAuth(mc.key, "")
-	resp, err := mc.client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-	bodyText, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	return mc.do("GET", path, nil, into)
+}
+
+// Fetches every page of a listing, following bookmarks until a page comes
+// back empty.
+func listAll[T any](mc *MochiClient, path string) ([]T, error) {
+	var all []T
+	bookmark := ""
+	for {
+		u := mc.baseURL + path
+		if bookmark != "" {
+			sep := "?"
+			if strings.Contains(u, "?") {
+				sep = "&"
+			}
+			u += sep + "bookmark=" + url.QueryEscape(bookmark)
+		}
+		var page Pagination[T]
+		if err := mc.getJSON(u, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Docs...)
+		if len(page.Docs) == 0 || page.Bookmark == "" || page.Bookmark == bookmark {
+			return all, nil
+		}
+		bookmark = page.Bookmark
 	}
-	return json.Unmarshal(bodyText, into)
 }
 
 func (mc *MochiClient) CreateCard(card Card) (*Card, error) {
 	var result Card
-	if err := mc.postJSON("https://app.mochi.cards/api/cards", card, &result); err != nil {
+	if err := mc.postJSON(mc.baseURL+"cards", card, &result); err != nil {
 		return &result, err
 	}
 	return &result, nil
 }
 
+// UpdateCard changes only the given fields of an existing card, keyed by
+// their JSON names, e.g. {"content": "...", "tags": [...]}. A whole Card
+// would reset every field it leaves zero.
+func (mc *MochiClient) UpdateCard(id string, changes map[string]any) (*Card, error) {
+	var result Card
+	if err := mc.postJSON(mc.baseURL+"cards/"+url.PathEscape(id), changes, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (mc *MochiClient) DeleteCard(id string) error {
+	return mc.do("DELETE", mc.baseURL+"cards/"+url.PathEscape(id), nil, nil)
+}
+
 type Pagination[T any] struct {
 	Bookmark string `json:"bookmark"`
 	Docs     []T    `json:"docs"`
@@ -113,8 +189,8 @@ type Card struct {
 	ID            string           `json:"id,omitempty"`
 	Content       string           `json:"content,omitempty"`
 	DeckID        string           `json:"deck-id"`
-	TemplateID    string           `json:"template-id"`
-	Fields        map[string]Field `json:"fields"`
+	TemplateID    string           `json:"template-id,omitempty"`
+	Fields        map[string]Field `json:"fields,omitempty"`
 	ReviewReverse bool             `json:"review-reverse?"`
 	Archived      bool             `json:"archived?"`
 	Pos           string           `json:"pos,omitempty"`
@@ -127,44 +203,32 @@ type Card struct {
 }
 
 type Template struct {
+	ID      string                   `json:"id"`
+	Name    string                   `json:"name"`
+	Content string                   `json:"content"`
+	Pos     string                   `json:"pos"`
+	Fields  map[string]TemplateField `json:"fields"`
+}
+
+type TemplateField struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
-	Content string `json:"content"`
 	Pos     string `json:"pos"`
-	Fields  map[string]struct {
-		ID      string `json:"id"`
-		Name    string `json:"name"`
-		Pos     string `json:"pos"`
-		Options struct {
-			MultiLine bool `json:"multi-line?"`
-		} `json:"options"`
-	} `json:"fields"`
+	Options struct {
+		MultiLine bool `json:"multi-line?"`
+	} `json:"options"`
 }
 
 func (mc *MochiClient) ListTemplates() ([]Template, error) {
-	decks := Pagination[Template]{}
-	if err := mc.getJSON("https://app.mochi.cards/api/templates", &decks); err != nil {
-		return nil, err
-	}
-	return decks.Docs, nil
+	return listAll[Template](mc, "templates")
 }
 
 func (mc *MochiClient) ListAllCards() ([]Card, error) {
-	decks := Pagination[Card]{}
-	if err := mc.getJSON("https://app.mochi.cards/api/cards", &decks); err != nil {
-		return nil, err
-	}
-	return decks.Docs, nil
+	return listAll[Card](mc, "cards")
 }
 
 func (mc *MochiClient) ListCardsInDeck(deckID string) ([]Card, error) {
-	decks := Pagination[Card]{}
-	// TODO uriencode
-	url := fmt.Sprintf("https://app.mochi.cards/api/cards?deck-id=%s", deckID)
-	if err := mc.getJSON(url, &decks); err != nil {
-		return nil, err
-	}
-	return decks.Docs, nil
+	return listAll[Card](mc, "cards?deck-id="+url.QueryEscape(deckID))
 }
 
 const defaultDeckID = "qyYRvdSD"
@@ -189,10 +253,12 @@ type EditTemplate struct {
 	SourceLang    string `yaml:"SourceLang"`
 	TargetExample string `yaml:"TargetExample,omitempty"`
 	SourceExample string `yaml:"SourceExample,omitempty"`
+	Notes         string `yaml:"Notes,omitempty"`   // mnemonic, cognates, etymology
+	Context       string `yaml:"Context,omitempty"` // sense and usage labels, e.g. "animal — informal"
 }
 
 func generateCards(deckID string, tmpl *EditTemplate) []Card {
-	return []Card{
+	cards := []Card{
 		{
 			DeckID:     deckID,                   // TODO derive
 			TemplateID: defaultForwardTemplateID, //TODO derive
@@ -242,4 +308,136 @@ func generateCards(deckID string, tmpl *EditTemplate) []Card {
 			Reviews: []any{},
 		},
 	}
+	// The templates have no notes or context fields, so notes go below the
+	// example and the context below the word it narrows down.
+	if tmpl.Notes != "" {
+		appendToField(cards[0].Fields, fwdTargetExampleFieldId, "\n\n", tmpl.Notes)
+		appendToField(cards[1].Fields, revTargetExampleFieldId, "\n\n", tmpl.Notes)
+	}
+	if tmpl.Context != "" {
+		appendToField(cards[0].Fields, fwdTargetLangFieldID, "\n", tmpl.Context)
+		appendToField(cards[1].Fields, revTargetLangFieldID, "\n", tmpl.Context)
+	}
+	return cards
+}
+
+// Appends text to the field's value, after sep if the field is not empty.
+func appendToField(fields map[string]Field, id, sep, text string) {
+	f := fields[id]
+	f.ID = id
+	if f.Value != "" {
+		f.Value += sep
+	}
+	f.Value += text
+	fields[id] = f
+}
+
+// cardKind selects which set of cards is generated for a phrase.
+type cardKind int
+
+const (
+	cardKindBasic cardKind = iota // forward/reverse pair from generateCards
+	cardKindCloze                 // single cloze card from generateClozeCard
+)
+
+// Cloze hints may not contain the "::" or "}}" delimiters.
+var clozeHintReplacer = strings.NewReplacer("::", ":", "}}", "}")
+
+// Builds a cloze deletion card from a phrase, hiding each highlighted span
+// behind Mochi's {{n::text::hint}} syntax with the translation as the hint.
+// Returns false if the phrase has no highlights to delete.
+func generateClozeCard(deckID string, p Phrase) (Card, bool) {
+	content := p.Source
+	hint := ""
+	if p.Target != "" {
+		hint = "::" + clozeHintReplacer.Replace(p.Target)
+	}
+	pos, deleted := 0, 0
+	for _, h := range p.Highlights {
+		idx := strings.Index(content[pos:], h)
+		if idx < 0 {
+			continue
+		}
+		idx += pos
+		deleted++
+		cloze := fmt.Sprintf("{{%d::%s%s}}", deleted, h, hint)
+		content = content[:idx] + cloze + content[idx+len(h):]
+		pos = idx + len(cloze)
+	}
+	if deleted == 0 {
+		return Card{}, false
+	}
+	return Card{
+		DeckID:  deckID,
+		Content: content,
+		Reviews: []any{},
+	}, true
+}
+
+// Builds a card quizzing a whole tense: the front names the verb and tense,
+// the back lists every person's form.
+func generateTenseCard(deckID string, conj *Conjugation, tense ConjTense) Card {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s** — %s (%s)\n---\n", conj.Verb, tense.Name, tense.Mood)
+	for _, f := range tense.Forms {
+		fmt.Fprintf(&sb, "- %s: **%s**\n", f.Person, f.Form)
+	}
+	return Card{
+		DeckID:  deckID,
+		Content: strings.TrimRight(sb.String(), "\n"),
+		Reviews: []any{},
+	}
+}
+
+// Deck for conjugation drill cards, from MOCHI_DRILL_DECK or the default deck.
+func drillDeckID() string {
+	if id := os.Getenv("MOCHI_DRILL_DECK"); id != "" {
+		return id
+	}
+	return defaultDeckID
+}
+
+// Builds one card per tense and person, e.g. "tener — pretérito — nosotros"
+// answered by "tuvimos". persons limits the people drilled; all are used if
+// it is empty. Irregular forms are tagged so they can be reviewed on their own.
+func generateDrillCards(deckID string, conj *Conjugation, tenses []ConjTense, persons []string) []Card {
+	want := map[string]bool{}
+	for _, p := range persons {
+		want[strings.ToLower(strings.TrimSpace(p))] = true
+	}
+	var cards []Card
+	for _, tense := range tenses {
+		for _, f := range tense.Forms {
+			if len(want) > 0 && !personMatches(f.Person, want) {
+				continue
+			}
+			tags := []string{"conjugation", tagName(conj.Verb), tagName(tense.Mood + " " + tense.Name)}
+			if f.Irregular {
+				tags = append(tags, "irregular")
+			}
+			cards = append(cards, Card{
+				DeckID:  deckID,
+				Content: fmt.Sprintf("**%s** — %s — %s\n---\n%s", conj.Verb, tense.Name, f.Person, f.Form),
+				Tags:    tags,
+				Reviews: []any{},
+			})
+		}
+	}
+	return cards
+}
+
+// Reports whether a person label such as "él, ella, usted" names any of the
+// wanted persons.
+func personMatches(label string, want map[string]bool) bool {
+	for _, p := range strings.Split(label, ",") {
+		if want[strings.ToLower(strings.TrimSpace(p))] {
+			return true
+		}
+	}
+	return false
+}
+
+// Mochi tags cannot contain spaces.
+func tagName(s string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), " ", "-")
 }
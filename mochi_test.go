@@ -0,0 +1,260 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Starts a fake Mochi and points NewMochiClient at it.
+func startFakeMochi(t *testing.T) (*fakeMochi, *MochiClient) {
+	t.Helper()
+	fake := newFakeMochi()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	t.Setenv("MOCHI_URL", srv.URL+"/api/")
+	t.Setenv("MOCHI_KEY", "test-key")
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	return fake, NewMochiClient("test-key", nil)
+}
+
+func TestMochiCardLifecycle(t *testing.T) {
+	fake, mc := startFakeMochi(t)
+
+	for i := range 25 {
+		deck := defaultDeckID
+		if i%5 == 0 {
+			deck = "drillDk1"
+		}
+		if _, err := mc.CreateCard(Card{DeckID: deck, Content: fmt.Sprintf("card %d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 20 cards at 10 per page: the client has to follow bookmarks.
+	cards, err := mc.ListCardsInDeck(defaultDeckID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cards) != 20 {
+		t.Fatalf("listed %d cards in deck, want 20", len(cards))
+	}
+	all, err := mc.ListAllCards()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 25 {
+		t.Fatalf("listed %d cards, want 25", len(all))
+	}
+
+	updated, err := mc.UpdateCard(cards[0].ID, map[string]any{"content": "edited", "tags": []string{"verb"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Content != "edited" || len(updated.Tags) != 1 || updated.DeckID != defaultDeckID || updated.UpdatedAt == nil {
+		t.Errorf("update gave %+v, want new content in the same deck", updated)
+	}
+
+	if err := mc.DeleteCard(cards[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(fake.Cards()); got != 24 {
+		t.Errorf("%d cards left after delete, want 24", got)
+	}
+	var mErr *MochiError
+	if err := mc.DeleteCard(cards[1].ID); !errors.As(err, &mErr) || mErr.Status != http.StatusNotFound {
+		t.Errorf("deleting twice gave %v, want a 404 MochiError", err)
+	}
+}
+
+func TestMochiListDecksAndTemplates(t *testing.T) {
+	_, mc := startFakeMochi(t)
+
+	decks, err := mc.ListDecks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decks) != 2 || decks[0].ID != defaultDeckID {
+		t.Errorf("got decks %+v", decks)
+	}
+	templates, err := mc.ListTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates) != 2 || templates[0].Fields[fwdTargetLangFieldID].ID != fwdTargetLangFieldID {
+		t.Errorf("got templates %+v", templates)
+	}
+}
+
+func TestMochiErrors(t *testing.T) {
+	fake, mc := startFakeMochi(t)
+
+	fake.failNext(http.StatusTooManyRequests)
+	if _, err := mc.ListDecks(); err != nil {
+		t.Errorf("rate limited request not retried: %v", err)
+	}
+
+	for range mochiRetries + 1 {
+		fake.failNext(http.StatusTooManyRequests)
+	}
+	var mErr *MochiError
+	if _, err := mc.ListDecks(); !errors.As(err, &mErr) || mErr.Status != http.StatusTooManyRequests {
+		t.Errorf("persistent rate limiting gave %v, want a 429 MochiError", err)
+	}
+
+	fake.failNext(http.StatusInternalServerError)
+	if _, err := mc.CreateCard(Card{DeckID: defaultDeckID}); !errors.As(err, &mErr) || mErr.Status != http.StatusInternalServerError {
+		t.Errorf("server error gave %v, want a 500 MochiError", err)
+	}
+
+	fake.malformNext()
+	if _, err := mc.ListAllCards(); err == nil || !strings.Contains(err.Error(), "invalid response") {
+		t.Errorf("malformed JSON gave %v, want an invalid response error", err)
+	}
+
+	if _, err := NewMochiClient("", nil).ListDecks(); !errors.As(err, &mErr) || mErr.Status != http.StatusUnauthorized {
+		t.Errorf("missing key gave %v, want a 401 MochiError", err)
+	}
+}
+
+func TestCardCommandsAgainstFakeMochi(t *testing.T) {
+	fake, _ := startFakeMochi(t)
+
+	yml := "TargetLang: dog (n)\nSourceLang: el perro (nm)\nContext: canine\n"
+	if res := createCardsCmd(apiClients{}, yml)().(addCardResultMsg); res.err != nil || res.count != 2 {
+		t.Fatalf("createCardsCmd: %d cards, err %v", res.count, res.err)
+	}
+	cards := fake.Cards()
+	if cards[0].TemplateID != defaultForwardTemplateID || cards[1].TemplateID != defaultReverseTemplateID {
+		t.Errorf("cards use templates %s and %s", cards[0].TemplateID, cards[1].TemplateID)
+	}
+	if got := cards[0].Fields[fwdTargetLangFieldID].Value; got != "dog (n)\ncanine" {
+		t.Errorf("word field is %q, want the context below the word", got)
+	}
+	if len(cards[1].Fields) != 4 {
+		t.Errorf("reverse card has fields %v, want only the template's four", cards[1].Fields)
+	}
+
+	phrase := Phrase{Source: "Mi perro duerme.", Target: "My dog sleeps.", Highlights: []string{"perro"}}
+	if res := createPhraseCardsCmd(apiClients{}, []Phrase{phrase}, cardKindCloze)().(addCardResultMsg); res.err != nil || res.count != 1 {
+		t.Fatalf("createPhraseCardsCmd: %d cards, err %v", res.count, res.err)
+	}
+	if got := fake.Cards()[2].Content; !strings.Contains(got, "{{1::perro::My dog sleeps.}}") {
+		t.Errorf("cloze card content %q", got)
+	}
+
+	decks := listDecksCmd(apiClients{})().(listDecksResultMsg)
+	if decks.err != nil || len(decks.decks) != 2 {
+		t.Errorf("listDecksCmd: %+v", decks)
+	}
+
+	fake.failNext(http.StatusInternalServerError)
+	res := createCardsCmd(apiClients{}, yml)().(addCardResultMsg)
+	if res.err == nil || res.count != 0 {
+		t.Errorf("createCardsCmd with a failing server: %d cards, err %v", res.count, res.err)
+	}
+}
+
+func TestGenerateClozeCard(t *testing.T) {
+	cases := []struct {
+		phrase Phrase
+		want   string
+	}{
+		{
+			Phrase{Source: "Mi perro duerme.", Target: "My dog sleeps.", Highlights: []string{"perro"}},
+			"Mi {{1::perro::My dog sleeps.}} duerme.",
+		},
+		{
+			Phrase{Source: "La casa y la casa.", Highlights: []string{"casa", "casa"}},
+			"La {{1::casa}} y la {{2::casa}}.",
+		},
+		{
+			Phrase{Source: "Un perro.", Target: "A {{dog}}:: here", Highlights: []string{"perro"}},
+			"Un {{1::perro::A {{dog}: here}}.",
+		},
+	}
+	for _, c := range cases {
+		card, ok := generateClozeCard(defaultDeckID, c.phrase)
+		if !ok || card.Content != c.want || card.DeckID != defaultDeckID {
+			t.Errorf("generateClozeCard(%+v) = %q, %v; want %q", c.phrase, card.Content, ok, c.want)
+		}
+	}
+	if _, ok := generateClozeCard(defaultDeckID, Phrase{Source: "Mi gato.", Highlights: []string{"perro"}}); ok {
+		t.Error("card generated without any span to delete")
+	}
+}
+
+func TestGenerateCardsNotes(t *testing.T) {
+	tmpl := &EditTemplate{TargetLang: "dog (n)", SourceLang: "el perro (nm)", TargetExample: "The dog barks.", Notes: "Mnemonic: a pear-o"}
+	cards := generateCards(defaultDeckID, tmpl)
+	if got := cards[0].Fields[fwdTargetExampleFieldId].Value; got != "The dog barks.\n\nMnemonic: a pear-o" {
+		t.Errorf("forward example field %q", got)
+	}
+	if got := cards[1].Fields[revTargetExampleFieldId].Value; got != "The dog barks.\n\nMnemonic: a pear-o" {
+		t.Errorf("reverse example field %q", got)
+	}
+	for _, card := range cards {
+		if len(card.Fields) != 4 {
+			t.Errorf("card has fields %v, want only the template's four", card.Fields)
+		}
+	}
+}
+
+func TestCreateCardsReportsStoreErrors(t *testing.T) {
+	startFakeMochi(t)
+	blocker := filepath.Join(t.TempDir(), "home")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ANKIBUILDER_HOME", filepath.Join(blocker, "data"))
+
+	res := createCardsCmd(apiClients{}, "TargetLang: dog (n)\nSourceLang: el perro (nm)\n")().(addCardResultMsg)
+	if res.err != nil || res.count != 2 || res.storeErr == nil {
+		t.Errorf("got %d cards, err %v, store error %v; want 2 cards and a store error", res.count, res.err, res.storeErr)
+	}
+}
+
+func TestGenerateDrillCardsPersons(t *testing.T) {
+	conj := &Conjugation{Verb: "tener", Tenses: []ConjTense{{Mood: "indicativo", Name: "presente", Forms: []ConjForm{
+		{Person: "yo", Form: "tengo", Irregular: true},
+		{Person: "tú", Form: "tienes", Irregular: true},
+		{Person: "él, ella, usted", Form: "tiene", Irregular: true},
+		{Person: "nosotros", Form: "tenemos"},
+	}}}}
+
+	cases := []struct {
+		persons []string
+		want    []string
+	}{
+		{nil, []string{"tengo", "tienes", "tiene", "tenemos"}},
+		{[]string{"él"}, []string{"tiene"}},
+		{[]string{"Usted", " nosotros"}, []string{"tiene", "tenemos"}},
+		{[]string{"ellos"}, nil},
+	}
+	for _, c := range cases {
+		var got []string
+		for _, card := range generateDrillCards("deck", conj, conj.Tenses, c.persons) {
+			_, answer, _ := strings.Cut(card.Content, "\n---\n")
+			got = append(got, answer)
+		}
+		if strings.Join(got, ",") != strings.Join(c.want, ",") {
+			t.Errorf("persons %q drilled %q, want %q", c.persons, got, c.want)
+		}
+	}
+}
+
+func TestFakeMochiRejectsUnknownFields(t *testing.T) {
+	startFakeMochi(t)
+	mc := NewMochiClient("key", nil)
+	card := generateCards(defaultDeckID, &EditTemplate{TargetLang: "dog (n)", SourceLang: "el perro (nm)"})[0]
+	card.Fields["context"] = Field{ID: "context", Value: "canine"}
+	var mErr *MochiError
+	if _, err := mc.CreateCard(card); !errors.As(err, &mErr) || mErr.Status != http.StatusUnprocessableEntity {
+		t.Errorf("a card with an unknown field gave %v, want a 422 MochiError", err)
+	}
+}
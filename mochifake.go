@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fakeMochi is an in-memory stand-in for the Mochi API, serving decks,
+// templates and cards the way MochiClient expects. Tests mount it on an
+// httptest server; MOCHI_FAKE=1 runs one inside the app for demos.
+type fakeMochi struct {
+	mu        sync.Mutex
+	decks     []Deck
+	templates []Template
+	cards     []Card
+	nextID    int
+	pageSize  int
+	failures  []fakeFailure
+}
+
+// A canned failure for the next request: an error status, or a 200 with a
+// body that is not valid JSON.
+type fakeFailure struct {
+	status    int
+	malformed bool
+}
+
+func newFakeMochi() *fakeMochi {
+	f := &fakeMochi{pageSize: 10}
+	f.decks = []Deck{
+		{ID: defaultDeckID, Name: "Spanish", TemplateID: defaultForwardTemplateID},
+		{ID: "drillDk1", Name: "Conjugations"},
+	}
+	f.templates = []Template{
+		fakeTemplate(defaultForwardTemplateID, "Spanish → English",
+			fwdSourceLangFieldID, fwdTargetLangFieldID, fwdSourceExampleFieldId, fwdTargetExampleFieldId),
+		fakeTemplate(defaultReverseTemplateID, "English → Spanish",
+			revSourceLangFieldID, revTargetLangFieldID, revSourceExampleFieldId, revTargetExampleFieldId),
+	}
+	return f
+}
+
+func fakeTemplate(id, name string, fieldIDs ...string) Template {
+	t := Template{ID: id, Name: name, Content: "<< " + fieldIDs[0] + " >>\n---\n<< " + fieldIDs[1] + " >>"}
+	t.Fields = map[string]TemplateField{}
+	for i, fid := range fieldIDs {
+		t.Fields[fid] = TemplateField{ID: fid, Name: fid, Pos: string(rune('a' + i))}
+	}
+	return t
+}
+
+// Checks that a card's template exists and has every field the card sets,
+// returning the error body to send or "".
+func (f *fakeMochi) checkFields(card Card) string {
+	if card.TemplateID == "" {
+		return ""
+	}
+	i := slices.IndexFunc(f.templates, func(t Template) bool { return t.ID == card.TemplateID })
+	if i < 0 {
+		return `{"errors":{"template-id":"not found"}}`
+	}
+	for id := range card.Fields {
+		if _, ok := f.templates[i].Fields[id]; !ok {
+			return fmt.Sprintf(`{"errors":{"fields":"template %s has no field %q"}}`, card.TemplateID, id)
+		}
+	}
+	return ""
+}
+
+// failNext makes the next request fail with status, e.g. 429 or 500.
+func (f *fakeMochi) failNext(status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, fakeFailure{status: status})
+}
+
+// malformNext makes the next request succeed with a truncated JSON body.
+func (f *fakeMochi) malformNext() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = append(f.failures, fakeFailure{status: http.StatusOK, malformed: true})
+}
+
+// Cards returns a copy of the cards currently stored.
+func (f *fakeMochi) Cards() []Card {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Card(nil), f.cards...)
+}
+
+func (f *fakeMochi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if key, _, ok := r.BasicAuth(); !ok || key == "" {
+		http.Error(w, `{"errors":{"auth":"missing API key"}}`, http.StatusUnauthorized)
+		return
+	}
+	if len(f.failures) > 0 {
+		fail := f.failures[0]
+		f.failures = f.failures[1:]
+		switch {
+		case fail.malformed:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"bookmark": "x", "docs": [{"id": `)
+			return
+		case fail.status == http.StatusTooManyRequests:
+			w.Header().Set("Retry-After", "0")
+		}
+		http.Error(w, `{"errors":{"server":"injected failure"}}`, fail.status)
+		return
+	}
+
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api"), "/")
+	collection, id, _ := strings.Cut(path, "/")
+	switch {
+	case collection == "decks" && r.Method == "GET" && id == "":
+		writePage(w, r, f.decks, func(d Deck) string { return d.ID }, f.pageSize)
+	case collection == "templates" && r.Method == "GET" && id == "":
+		writePage(w, r, f.templates, func(t Template) string { return t.ID }, f.pageSize)
+	case collection == "cards" && r.Method == "GET" && id == "":
+		cards := f.cards
+		if deck := r.URL.Query().Get("deck-id"); deck != "" {
+			cards = nil
+			for _, c := range f.cards {
+				if c.DeckID == deck {
+					cards = append(cards, c)
+				}
+			}
+		}
+		writePage(w, r, cards, func(c Card) string { return c.ID }, f.pageSize)
+	case collection == "cards" && r.Method == "GET":
+		if i := f.cardIndex(id); i >= 0 {
+			writeJSON(w, f.cards[i])
+			return
+		}
+		http.NotFound(w, r)
+	case collection == "cards" && r.Method == "POST" && id == "":
+		var card Card
+		if err := json.NewDecoder(r.Body).Decode(&card); err != nil {
+			http.Error(w, `{"errors":{"body":"invalid JSON"}}`, http.StatusBadRequest)
+			return
+		}
+		if card.DeckID == "" {
+			http.Error(w, `{"errors":{"deck-id":"required"}}`, http.StatusUnprocessableEntity)
+			return
+		}
+		if msg := f.checkFields(card); msg != "" {
+			http.Error(w, msg, http.StatusUnprocessableEntity)
+			return
+		}
+		f.nextID++
+		card.ID = fmt.Sprintf("card%04d", f.nextID)
+		card.CreatedAt = &MochiTime{Date: time.Now().UTC()}
+		isNew := true
+		card.New = &isNew
+		f.cards = append(f.cards, card)
+		writeJSON(w, card)
+	case collection == "cards" && r.Method == "POST":
+		i := f.cardIndex(id)
+		if i < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		// Only the fields present in the request change.
+		current, _ := json.Marshal(f.cards[i])
+		var merged map[string]any
+		json.Unmarshal(current, &merged)
+		if err := json.NewDecoder(r.Body).Decode(&merged); err != nil {
+			http.Error(w, `{"errors":{"body":"invalid JSON"}}`, http.StatusBadRequest)
+			return
+		}
+		updated, _ := json.Marshal(merged)
+		var card Card
+		json.Unmarshal(updated, &card)
+		if msg := f.checkFields(card); msg != "" {
+			http.Error(w, msg, http.StatusUnprocessableEntity)
+			return
+		}
+		card.ID = id
+		card.UpdatedAt = &MochiTime{Date: time.Now().UTC()}
+		f.cards[i] = card
+		writeJSON(w, card)
+	case collection == "cards" && r.Method == "DELETE":
+		i := f.cardIndex(id)
+		if i < 0 {
+			http.NotFound(w, r)
+			return
+		}
+		f.cards = append(f.cards[:i], f.cards[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeMochi) cardIndex(id string) int {
+	for i, c := range f.cards {
+		if c.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Writes one page of items. The bookmark is the ID of the last item on the
+// page; a request past the end gets no docs and the same bookmark back, as
+// Mochi does.
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T, idOf func(T) string, pageSize int) {
+	if limit, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && limit > 0 {
+		pageSize = limit
+	}
+	bookmark := r.URL.Query().Get("bookmark")
+	start := 0
+	if bookmark != "" {
+		start = len(items)
+		for i, item := range items {
+			if idOf(item) == bookmark {
+				start = i + 1
+				break
+			}
+		}
+	}
+	end := min(start+pageSize, len(items))
+	page := Pagination[T]{Bookmark: bookmark, Docs: append([]T{}, items[start:end]...)}
+	if end > start {
+		page.Bookmark = idOf(items[end-1])
+	}
+	writeJSON(w, page)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Starts a fake Mochi on a local port when MOCHI_FAKE is set and points
+// MochiClient at it, so card commands can be demoed without an account.
+func fakeMochiFromEnv() error {
+	if os.Getenv("MOCHI_FAKE") == "" {
+		return nil
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+	go http.Serve(ln, newFakeMochi())
+	os.Setenv("MOCHI_URL", "http://"+ln.Addr().String()+"/api/")
+	if os.Getenv("MOCHI_KEY") == "" {
+		os.Setenv("MOCHI_KEY", "fake")
+	}
+	return nil
+}
@@ -2,36 +2,69 @@ package main
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultOpenAIModel = "gpt-4o-mini"
+	defaultOpenAIURL   = "https://api.openai.com/v1"
 )
 
 type OpenAIClient struct {
-	key    string
-	client *http.Client
+	key     string
+	model   string
+	baseURL string
+	client  *http.Client
+	usage   *usageLedger
 }
 
-func NewOpenAIClient() *OpenAIClient {
+// NewOpenAIClient uses OPENAI_BASE_URL, like the official SDKs, to reach
+// a proxy or compatible server instead of api.openai.com. Requests go
+// through transport (http.DefaultTransport if nil).
+func NewOpenAIClient(transport http.RoundTripper) *OpenAIClient {
 	return &OpenAIClient{
-		key:    os.Getenv("OPENAI_API_KEY"),
-		client: &http.Client{},
+		key:     os.Getenv("OPENAI_API_KEY"),
+		model:   defaultOpenAIModel,
+		baseURL: strings.TrimSuffix(cmp.Or(os.Getenv("OPENAI_BASE_URL"), defaultOpenAIURL), "/"),
+		client:  newHTTPClient(transport),
+		usage:   llmUsage,
 	}
 }
 
-func (c *OpenAIClient) ChatCompletion(systemPrompt, userPrompt string) (string, error) {
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatCompletion sends a single system+user exchange and returns the reply.
+// Token usage is recorded in the usage ledger under purpose.
+func (c *OpenAIClient) ChatCompletion(purpose, systemPrompt, userPrompt string) (string, error) {
+	return c.Chat(purpose, []chatMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userPrompt},
+	})
+}
+
+// Chat sends a full conversation and returns the assistant's reply.
+func (c *OpenAIClient) Chat(purpose string, messages []chatMessage) (string, error) {
 	if c.key == "" {
 		return "", fmt.Errorf("OPENAI_API_KEY is not set")
 	}
+	if err := c.usage.checkBudget(); err != nil {
+		return "", err
+	}
 
 	body := map[string]any{
-		"model": "gpt-4o-mini",
-		"messages": []map[string]string{
-			{"role": "system", "content": systemPrompt},
-			{"role": "user", "content": userPrompt},
-		},
+		"model":    c.model,
+		"messages": messages,
 	}
 
 	jsonBody, err := json.Marshal(body)
@@ -39,7 +72,7 @@ func (c *OpenAIClient) ChatCompletion(systemPrompt, userPrompt string) (string,
 		return "", fmt.Errorf("marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewReader(jsonBody))
+	req, err := http.NewRequest("POST", c.baseURL+"/chat/completions", bytes.NewReader(jsonBody))
 	if err != nil {
 		return "", fmt.Errorf("create request: %w", err)
 	}
@@ -67,11 +100,28 @@ func (c *OpenAIClient) ChatCompletion(systemPrompt, userPrompt string) (string,
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage struct {
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+		} `json:"usage"`
 	}
 	if err := json.Unmarshal(respBody, &result); err != nil {
 		return "", fmt.Errorf("parse response: %w", err)
 	}
 
+	if err := c.usage.record(usageRecord{
+		Time:             time.Now(),
+		Model:            c.model,
+		Purpose:          purpose,
+		PromptTokens:     result.Usage.PromptTokens,
+		CompletionTokens: result.Usage.CompletionTokens,
+		Cost:             costFor(c.model, result.Usage.PromptTokens, result.Usage.CompletionTokens),
+	}); err != nil {
+		// The reply is already paid for; losing the ledger entry is not worth
+		// losing it too.
+		log.Printf("record LLM usage: %v", err)
+	}
+
 	if len(result.Choices) == 0 {
 		return "", fmt.Errorf("no choices in response")
 	}
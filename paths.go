@@ -0,0 +1,24 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Returns the path of name inside the application's data directory,
+// creating the directory if needed. ANKIBUILDER_HOME overrides the default
+// location under the user's config directory.
+func dataPath(name string) (string, error) {
+	dir := os.Getenv("ANKIBUILDER_HOME")
+	if dir == "" {
+		base, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(base, "ankibuilder")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// DictionaryProvider is a source of translations. WordReference and the
+// offline Wiktionary extract implement it, and providers can be wrapped for
+// caching or combined.
+type DictionaryProvider interface {
+	Name() string
+	Lookup(word string) (*Translation, error)
+	Pairs() []LanguagePair
+	Capabilities() Capability
+}
+
+type LanguagePair struct {
+	Code string // e.g. "enes"
+	From string
+	To   string
+}
+
+// Capability is a set of optional features a provider supports.
+type Capability uint
+
+const (
+	CapConjugation Capability = 1 << iota // verb conjugation tables
+	CapExamples                           // example sentences with entries
+	CapOffline                            // works without network access
+)
+
+func (c Capability) Has(other Capability) bool {
+	return c&other == other
+}
+
+// WordReference
+
+func (wr *WordReference) Name() string { return "WordReference" }
+
+func (wr *WordReference) Lookup(word string) (*Translation, error) {
+	return wr.Translate(word)
+}
+
+func (wr *WordReference) Pairs() []LanguagePair {
+	var pairs []LanguagePair
+	for code, langs := range wr.available {
+		pairs = append(pairs, LanguagePair{Code: code, From: langs["from"], To: langs["to"]})
+	}
+	return pairs
+}
+
+func (wr *WordReference) Capabilities() Capability {
+	return CapConjugation | CapExamples
+}
+
+// Wiktionary
+
+func (wk *Wiktionary) Name() string { return "Wiktionary" }
+
+func (wk *Wiktionary) Lookup(word string) (*Translation, error) {
+	return wk.Translate(word)
+}
+
+func (wk *Wiktionary) Pairs() []LanguagePair {
+	return []LanguagePair{{Code: languageCode(wk.FromLang) + languageCode(wk.ToLang), From: wk.FromLang, To: wk.ToLang}}
+}
+
+func (wk *Wiktionary) Capabilities() Capability {
+	return CapExamples | CapOffline
+}
+
+// fallbackProvider returns the first successful lookup from its providers
+// in order, or the first provider's error if all fail.
+type fallbackProvider struct {
+	providers []DictionaryProvider
+}
+
+func (fp *fallbackProvider) Name() string {
+	return strings.Join(Map(fp.providers, DictionaryProvider.Name), " → ")
+}
+
+func (fp *fallbackProvider) Lookup(word string) (*Translation, error) {
+	var firstErr error
+	for _, p := range fp.providers {
+		translation, err := p.Lookup(word)
+		if err == nil {
+			return translation, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return nil, firstErr
+}
+
+func (fp *fallbackProvider) Pairs() []LanguagePair {
+	return unionPairs(fp.providers)
+}
+
+func (fp *fallbackProvider) Capabilities() Capability {
+	return unionCapabilities(fp.providers)
+}
+
+// compositeProvider looks a word up in every provider and merges the
+// results, prefixing each section title with the provider's name.
+type compositeProvider struct {
+	providers []DictionaryProvider
+}
+
+func (cp *compositeProvider) Name() string {
+	return strings.Join(Map(cp.providers, DictionaryProvider.Name), " + ")
+}
+
+func (cp *compositeProvider) Lookup(word string) (*Translation, error) {
+	var merged *Translation
+	var errs []string
+	for _, p := range cp.providers {
+		translation, err := p.Lookup(word)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", p.Name(), err))
+			continue
+		}
+		if merged == nil {
+			merged = &Translation{
+				Word:     translation.Word,
+				FromLang: translation.FromLang,
+				ToLang:   translation.ToLang,
+				URL:      translation.URL,
+			}
+		}
+		for _, section := range translation.Translations {
+			section.Title = p.Name() + ": " + strings.TrimPrefix(section.Title, p.Name()+": ")
+			merged.Translations = append(merged.Translations, section)
+		}
+	}
+	if merged == nil {
+		return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return merged, nil
+}
+
+func (cp *compositeProvider) Pairs() []LanguagePair {
+	return unionPairs(cp.providers)
+}
+
+func (cp *compositeProvider) Capabilities() Capability {
+	return unionCapabilities(cp.providers)
+}
+
+// cachedProvider remembers successful lookups for the life of the process.
+// Callers get their own copy of a cached translation, so changing one does
+// not change the cache.
+type cachedProvider struct {
+	DictionaryProvider
+	mu    sync.Mutex
+	cache map[string]*Translation
+}
+
+func newCachedProvider(p DictionaryProvider) *cachedProvider {
+	return &cachedProvider{DictionaryProvider: p, cache: map[string]*Translation{}}
+}
+
+func (cp *cachedProvider) Lookup(word string) (*Translation, error) {
+	key := strings.ToLower(word)
+	cp.mu.Lock()
+	translation, ok := cp.cache[key]
+	cp.mu.Unlock()
+	if ok {
+		return translation.clone(), nil
+	}
+	translation, err := cp.DictionaryProvider.Lookup(word)
+	if err != nil {
+		return nil, err
+	}
+	cp.mu.Lock()
+	cp.cache[key] = translation.clone()
+	cp.mu.Unlock()
+	return translation, nil
+}
+
+// Returns the first of p and the providers it wraps that implements T, for
+// reaching optional features such as conjugation through wrappers.
+func providerAs[T any](p DictionaryProvider) (T, bool) {
+	if t, ok := p.(T); ok {
+		return t, true
+	}
+	var inner []DictionaryProvider
+	switch p := p.(type) {
+	case *cachedProvider:
+		inner = []DictionaryProvider{p.DictionaryProvider}
+	case *fallbackProvider:
+		inner = p.providers
+	case *compositeProvider:
+		inner = p.providers
+	}
+	for _, ip := range inner {
+		if t, ok := providerAs[T](ip); ok {
+			return t, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+func unionPairs(providers []DictionaryProvider) []LanguagePair {
+	seen := map[string]bool{}
+	var pairs []LanguagePair
+	for _, p := range providers {
+		for _, pair := range p.Pairs() {
+			if !seen[pair.Code] {
+				seen[pair.Code] = true
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+	return pairs
+}
+
+func unionCapabilities(providers []DictionaryProvider) Capability {
+	var c Capability
+	for _, p := range providers {
+		c |= p.Capabilities()
+	}
+	return c
+}
+
+// Builds the provider for one lookup direction. wr may be nil when
+// WordReference is unreachable, and wk is nil unless the offline extract
+// covers this direction. ANKIBUILDER_DICT selects "wordreference" (the
+// default, falling back to Wiktionary), "wiktionary" (falling back to
+// WordReference) or "all" to merge both.
+//
+// There are no profiles: ANKIBUILDER_DICT and WIKTIONARY_JSONL apply to
+// the whole process, so another setup needs its own environment, e.g. a
+// shell alias that also sets ANKIBUILDER_HOME.
+func loadProvider(wr *WordReference, wk *Wiktionary) (DictionaryProvider, error) {
+	var providers []DictionaryProvider
+	if wr != nil {
+		providers = append(providers, wr)
+	}
+	if wk != nil {
+		switch os.Getenv("ANKIBUILDER_DICT") {
+		case "wiktionary":
+			providers = append([]DictionaryProvider{wk}, providers...)
+		default:
+			providers = append(providers, wk)
+		}
+	}
+
+	switch dict := os.Getenv("ANKIBUILDER_DICT"); dict {
+	case "", "wordreference", "wiktionary", "all":
+		if len(providers) == 0 {
+			return nil, fmt.Errorf("no dictionary available")
+		}
+		if dict == "all" {
+			return newCachedProvider(&compositeProvider{providers: providers}), nil
+		}
+		return newCachedProvider(&fallbackProvider{providers: providers}), nil
+	default:
+		return nil, fmt.Errorf("unknown dictionary %q", dict)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// fakeProvider serves canned translations. Its name defaults to "Fake" and
+// its capabilities to CapOffline.
+type fakeProvider struct {
+	Translations map[string]*Translation
+	ProviderName string
+	Caps         Capability
+}
+
+func (fp *fakeProvider) Name() string { return cmp.Or(fp.ProviderName, "Fake") }
+
+func (fp *fakeProvider) Lookup(word string) (*Translation, error) {
+	if t, ok := fp.Translations[word]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("no entry for %q", word)
+}
+
+func (fp *fakeProvider) Pairs() []LanguagePair {
+	return []LanguagePair{{Code: "enes", From: "English", To: "Spanish"}}
+}
+
+func (fp *fakeProvider) Capabilities() Capability {
+	return cmp.Or(fp.Caps, CapOffline)
+}
+
+func TestCachedProviderReturnsCopies(t *testing.T) {
+	inner := &fakeProvider{Translations: map[string]*Translation{"dog": testTranslation("dog", 2)}}
+	cp := newCachedProvider(inner)
+
+	first, err := cp.Lookup("dog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.Translations[0].Entries[0].ToWords[0].Meaning = "changed"
+	first.Translations[0].Entries = first.Translations[0].Entries[:1]
+
+	delete(inner.Translations, "dog") // a second lookup must come from the cache
+	second, err := cp.Lookup("Dog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := flattenEntries(second)
+	if len(entries) != 2 || entries[0].ToWords[0].Meaning != "perro1" {
+		t.Errorf("cached translation was changed through a returned copy: %+v", entries)
+	}
+}
+
+func TestFallbackProvider(t *testing.T) {
+	empty := &fakeProvider{}
+	full := &fakeProvider{Translations: map[string]*Translation{"dog": testTranslation("dog", 1)}}
+	fp := &fallbackProvider{providers: []DictionaryProvider{empty, full}}
+	if tr, err := fp.Lookup("dog"); err != nil || tr.Word != "dog" {
+		t.Errorf("fallback lookup gave %v, %v", tr, err)
+	}
+	if _, err := fp.Lookup("cat"); err == nil || err.Error() != `no entry for "cat"` {
+		t.Errorf("failed lookup gave %v, want the first provider's error", err)
+	}
+}
+
+func TestCompositeProvider(t *testing.T) {
+	online := &fakeProvider{ProviderName: "Online", Caps: CapConjugation | CapExamples,
+		Translations: map[string]*Translation{"dog": testTranslation("dog", 2, "hot dog")}}
+	offline := &fakeProvider{ProviderName: "Offline",
+		Translations: map[string]*Translation{"dog": testTranslation("dog", 1), "cat": testTranslation("cat", 1)}}
+	cp := &compositeProvider{providers: []DictionaryProvider{online, offline}}
+
+	if got := cp.Capabilities(); got != CapConjugation|CapExamples|CapOffline {
+		t.Errorf("capabilities %b, want the union of both", got)
+	}
+	if got := cp.Name(); got != "Online + Offline" {
+		t.Errorf("name %q", got)
+	}
+
+	tr, err := cp.Lookup("dog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	titles := Map(tr.Translations, func(s TranslationSection) string { return s.Title })
+	want := []string{"Online: Principal Translations", "Online: Compound Forms", "Offline: Principal Translations"}
+	if strings.Join(titles, "|") != strings.Join(want, "|") {
+		t.Errorf("sections %q, want %q", titles, want)
+	}
+	if got := len(flattenEntries(tr)); got != 4 {
+		t.Errorf("merged %d entries, want 4", got)
+	}
+	if online.Translations["dog"].Translations[0].Title != "Principal Translations" {
+		t.Error("merging renamed the provider's own section")
+	}
+
+	// A provider that fails is skipped; only when all fail is it an error.
+	tr, err = cp.Lookup("cat")
+	if err != nil || len(tr.Translations) != 1 || tr.Translations[0].Title != "Offline: Principal Translations" {
+		t.Errorf("cat with one failing provider gave %+v, %v", tr, err)
+	}
+	if _, err := cp.Lookup("bird"); err == nil || err.Error() != `Online: no entry for "bird"; Offline: no entry for "bird"` {
+		t.Errorf("bird gave %v, want both providers' errors", err)
+	}
+}
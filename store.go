@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// vocabStore is the local record of words that already have cards and of
+// successful lookups, kept in known.json in the data directory.
+type vocabStore struct {
+	mu      sync.Mutex
+	path    string
+	Known   map[string]time.Time `json:"known"`
+	History map[string]int       `json:"history"`         // word -> lookup count
+	Langs   map[string]string    `json:"langs,omitempty"` // word -> language it was looked up as
+}
+
+func openVocabStore() (*vocabStore, error) {
+	path, err := dataPath("known.json")
+	if err != nil {
+		return nil, err
+	}
+	vs := &vocabStore{path: path, Known: map[string]time.Time{}, History: map[string]int{}, Langs: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return vs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, vs); err != nil {
+		return nil, err
+	}
+	if vs.Known == nil {
+		vs.Known = map[string]time.Time{}
+	}
+	if vs.History == nil {
+		vs.History = map[string]int{}
+	}
+	if vs.Langs == nil {
+		vs.Langs = map[string]string{}
+	}
+	return vs, nil
+}
+
+// Normalizes Spanish card text to the headword stored as known, dropping
+// grammar annotations and articles such as "la casa (nf)".
+func knownWord(s string) string {
+	s, _, _ = strings.Cut(s, "\n")
+	s, _, _ = strings.Cut(s, " (")
+	s = strings.ToLower(strings.TrimSpace(s))
+	if article, rest, ok := strings.Cut(s, " "); ok && isArticle("es", article) {
+		s = rest
+	}
+	return s
+}
+
+func (vs *vocabStore) Has(word string) bool {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	_, ok := vs.Known[knownWord(word)]
+	return ok
+}
+
+func (vs *vocabStore) Add(words ...string) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	now := time.Now()
+	for _, w := range words {
+		if w = knownWord(w); w != "" {
+			vs.Known[w] = now
+		}
+	}
+	return vs.save()
+}
+
+func (vs *vocabStore) RecordLookup(word, lang string) error {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	word = strings.ToLower(strings.TrimSpace(word))
+	vs.History[word]++
+	vs.Langs[word] = lang
+	return vs.save()
+}
+
+// Returns the language word was last found in, "en" or "es", or "" if it
+// has not been looked up.
+func (vs *vocabStore) LookupLang(word string) string {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	return vs.Langs[strings.ToLower(strings.TrimSpace(word))]
+}
+
+// Words returns every word in the store, known or looked up.
+func (vs *vocabStore) Words() []string {
+	vs.mu.Lock()
+	defer vs.mu.Unlock()
+	words := make([]string, 0, len(vs.Known)+len(vs.History))
+	for w := range vs.Known {
+		words = append(words, w)
+	}
+	for w := range vs.History {
+		if _, ok := vs.Known[w]; !ok {
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// Callers must hold vs.mu.
+func (vs *vocabStore) save() error {
+	data, err := json.MarshalIndent(vs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(vs.path, data, 0o644)
+}
+
+// Records words as known after their cards were created. Callers report the
+// error as a warning, since the cards themselves were created.
+func rememberWords(words ...string) error {
+	vs, err := openVocabStore()
+	if err == nil {
+		err = vs.Add(words...)
+	}
+	if err != nil {
+		return fmt.Errorf("record known words: %w", err)
+	}
+	return nil
+}
+
+// Records a successful lookup in the history. Like rememberWords, an error
+// is a warning rather than a failed lookup.
+func rememberLookup(word, lang string) error {
+	vs, err := openVocabStore()
+	if err == nil {
+		err = vs.RecordLookup(word, lang)
+	}
+	if err != nil {
+		return fmt.Errorf("record lookup history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// nearWordLister is implemented by providers that can list their headwords
+// close to a word, such as the offline Wiktionary extract.
+type nearWordLister interface {
+	// WordsNear returns the headwords that could be within two edits of
+	// word: those with the same first letter, ignoring accents, and a length
+	// within two of it.
+	WordsNear(word string) []string
+}
+
+var accentFolds = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+	"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u",
+	"â", "a", "ê", "e", "î", "i", "ô", "o", "û", "u",
+	"ä", "a", "ë", "e", "ï", "i", "ö", "o", "ç", "c",
+)
+
+// Lowercases s and strips diacritics, so "Niño" and "nino" compare equal.
+func foldAccents(s string) string {
+	return accentFolds.Replace(strings.ToLower(s))
+}
+
+// Levenshtein distance between a and b, counted in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// Collects the local words suggestions for word are drawn from: the lookup
+// history, known words and the dictionary's headwords near word.
+func localWords(dict DictionaryProvider, word string) []string {
+	var words []string
+	if vs, err := openVocabStore(); err == nil {
+		words = append(words, vs.Words()...)
+	}
+	if wl, ok := providerAs[nearWordLister](dict); ok {
+		words = append(words, wl.WordsNear(word)...)
+	}
+	return words
+}
+
+// Buckets words by folded first letter and length in runes, so the words
+// within two edits of a word are found in five buckets.
+func nearKey(folded string, length int) string {
+	r, _ := utf8.DecodeRuneInString(folded)
+	return string(r) + strconv.Itoa(length)
+}
+
+// Returns up to limit spelling suggestions for word. Suggestions from the
+// dictionary's no-entry page come first, then local words that match
+// ignoring accents, then local words within two edits.
+func suggestWords(word string, remote, local []string, limit int) []string {
+	folded := foldAccents(word)
+	seen := map[string]bool{strings.ToLower(word): true}
+	var out []string
+	add := func(w string) {
+		if !seen[strings.ToLower(w)] && len(out) < limit {
+			seen[strings.ToLower(w)] = true
+			out = append(out, w)
+		}
+	}
+	for _, w := range remote {
+		add(w)
+	}
+
+	type scored struct {
+		word string
+		dist int
+	}
+	var near []scored
+	for _, w := range local {
+		fw := foldAccents(w)
+		if fw == folded {
+			near = append(near, scored{w, 0})
+			continue
+		}
+		if d := len(fw) - len(folded); d > 2 || d < -2 {
+			continue
+		}
+		if d := editDistance(fw, folded); d <= 2 {
+			near = append(near, scored{w, d})
+		}
+	}
+	sort.SliceStable(near, func(i, j int) bool {
+		if near[i].dist != near[j].dist {
+			return near[i].dist < near[j].dist
+		}
+		return near[i].word < near[j].word
+	})
+	for _, s := range near {
+		add(s.word)
+	}
+	return out
+}
+
+// Suggestions for a failed lookup of word.
+func suggestionsFor(dict DictionaryProvider, word string, err error) []string {
+	var remote []string
+	var noEntry *NoEntryError
+	if errors.As(err, &noEntry) {
+		remote = noEntry.Suggestions
+	}
+	return suggestWords(word, remote, localWords(dict, word), 8)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tatoeba language codes for the session's language pair.
+const (
+	tatoebaSourceLang = "spa"
+	tatoebaTargetLang = "eng"
+)
+
+// tatoebaPair is a human-translated sentence pair from a Tatoeba dump.
+type tatoebaPair struct {
+	Source string
+	Target string
+}
+
+// tatoebaCorpus holds the imported pairs for one language pair with a word
+// index over the source sentences.
+type tatoebaCorpus struct {
+	Pairs []tatoebaPair
+	index map[string][]int
+}
+
+func tatoebaPath(from, to string) (string, error) {
+	return dataPath(fmt.Sprintf("tatoeba-%s-%s.tsv", from, to))
+}
+
+// Imports a Tatoeba sentences dump (id, lang, text) and links dump (id, id)
+// and stores every from-language sentence paired with its to-language
+// translations. Either dump may be the full export or a per-language one.
+func importTatoeba(sentencesFile, linksFile, from, to string) (int, error) {
+	sentences := map[string]string{}
+	langs := map[string]string{}
+	err := readTSV(sentencesFile, 3, func(cols []string) {
+		if cols[1] == from || cols[1] == to {
+			sentences[cols[0]] = cols[2]
+			langs[cols[0]] = cols[1]
+		}
+	})
+	if err != nil {
+		return 0, fmt.Errorf("read sentences: %w", err)
+	}
+
+	path, err := tatoebaPath(from, to)
+	if err != nil {
+		return 0, err
+	}
+	// Write next to the corpus and swap it in only once the import is
+	// complete, so a failed import leaves the previous corpus intact.
+	out, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+	w := bufio.NewWriter(out)
+
+	count := 0
+	err = readTSV(linksFile, 2, func(cols []string) {
+		if langs[cols[0]] != from || langs[cols[1]] != to {
+			return
+		}
+		fmt.Fprintf(w, "%s\t%s\n", sentences[cols[0]], sentences[cols[1]])
+		count++
+	})
+	if err != nil {
+		return 0, fmt.Errorf("read links: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return 0, err
+	}
+	if err := out.Close(); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(out.Name(), path); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Calls fn with the columns of each tab-separated line that has at least n
+// columns.
+func readTSV(file string, n int, fn func([]string)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadString('\n')
+		if cols := strings.Split(strings.TrimRight(line, "\r\n"), "\t"); len(cols) >= n {
+			fn(cols)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// The most recently loaded corpus, reused until its file changes.
+var tatoebaLoaded struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	corpus  *tatoebaCorpus
+}
+
+// Loads the corpus for a language pair and indexes it, once per import.
+func loadTatoeba(from, to string) (*tatoebaCorpus, error) {
+	path, err := tatoebaPath(from, to)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no Tatoeba corpus for %s-%s; import one with /tatoeba", from, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tatoebaLoaded.mu.Lock()
+	defer tatoebaLoaded.mu.Unlock()
+	if tatoebaLoaded.path == path && tatoebaLoaded.modTime.Equal(info.ModTime()) && tatoebaLoaded.size == info.Size() {
+		return tatoebaLoaded.corpus, nil
+	}
+	c, err := readTatoeba(path)
+	if err != nil {
+		return nil, err
+	}
+	tatoebaLoaded.path, tatoebaLoaded.modTime, tatoebaLoaded.size = path, info.ModTime(), info.Size()
+	tatoebaLoaded.corpus = c
+	return c, nil
+}
+
+func readTatoeba(path string) (*tatoebaCorpus, error) {
+	c := &tatoebaCorpus{index: map[string][]int{}}
+	err := readTSV(path, 2, func(cols []string) {
+		i := len(c.Pairs)
+		c.Pairs = append(c.Pairs, tatoebaPair{Source: cols[0], Target: cols[1]})
+		for _, w := range tokenize(cols[0]) {
+			if ids := c.index[w]; len(ids) == 0 || ids[len(ids)-1] != i {
+				c.index[w] = append(ids, i)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Returns up to limit pairs whose source sentence contains word, shortest
+// first, as phrases with the word highlighted.
+func (c *tatoebaCorpus) examples(word string, limit int) []Phrase {
+	word = strings.ToLower(word)
+	ids := c.index[word]
+	sorted := append([]int(nil), ids...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return len(c.Pairs[sorted[i]].Source) < len(c.Pairs[sorted[j]].Source)
+	})
+	var phrases []Phrase
+	seen := map[string]bool{}
+	for _, i := range sorted {
+		p := c.Pairs[i]
+		if seen[p.Source] {
+			continue
+		}
+		seen[p.Source] = true
+		phrases = append(phrases, Phrase{
+			Source:     p.Source,
+			Target:     p.Target,
+			Highlights: []string{matchedForm(p.Source, word)},
+		})
+		if len(phrases) == limit {
+			break
+		}
+	}
+	return phrases
+}
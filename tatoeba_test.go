@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTatoebaDumps(t *testing.T, sentences, links string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	sPath, lPath := filepath.Join(dir, "sentences.csv"), filepath.Join(dir, "links.csv")
+	if err := os.WriteFile(sPath, []byte(sentences), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lPath, []byte(links), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return sPath, lPath
+}
+
+func TestTatoebaImportAndExamples(t *testing.T) {
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	sentences, links := writeTatoebaDumps(t,
+		"1\tspa\tEl perro duerme en la casa.\n2\teng\tThe dog sleeps in the house.\n3\tspa\tMi perro ladra.\n4\teng\tMy dog barks.\n5\tfra\tMon chien aboie.\n",
+		"1\t2\n2\t1\n3\t4\n3\t5\n")
+
+	count, err := importTatoeba(sentences, links, tatoebaSourceLang, tatoebaTargetLang)
+	if err != nil || count != 2 {
+		t.Fatalf("imported %d pairs, err %v; want 2", count, err)
+	}
+	corpus, err := loadTatoeba(tatoebaSourceLang, tatoebaTargetLang)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := corpus.examples("Perro", 10)
+	if len(got) != 2 || got[0].Source != "Mi perro ladra." || got[0].Target != "My dog barks." || got[0].Highlights[0] != "perro" {
+		t.Errorf("examples for perro: %+v", got)
+	}
+	if again, _ := loadTatoeba(tatoebaSourceLang, tatoebaTargetLang); again != corpus {
+		t.Error("corpus re-indexed although the file did not change")
+	}
+
+	// A failed import keeps the corpus that was there.
+	if _, err := importTatoeba(sentences, filepath.Join(t.TempDir(), "missing.csv"), tatoebaSourceLang, tatoebaTargetLang); err == nil {
+		t.Fatal("import with a missing links file succeeded")
+	}
+	corpus, err = loadTatoeba(tatoebaSourceLang, tatoebaTargetLang)
+	if err != nil || len(corpus.Pairs) != 2 {
+		t.Fatalf("after a failed import: %v pairs, err %v", corpus, err)
+	}
+	path, _ := tatoebaPath(tatoebaSourceLang, tatoebaTargetLang)
+	if leftovers, _ := filepath.Glob(path + ".*.tmp"); len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+
+	// A new import replaces the corpus and its index.
+	_, fewerLinks := writeTatoebaDumps(t, "", "1\t2\n")
+	if _, err := importTatoeba(sentences, fewerLinks, tatoebaSourceLang, tatoebaTargetLang); err != nil {
+		t.Fatal(err)
+	}
+	if corpus, _ := loadTatoeba(tatoebaSourceLang, tatoebaTargetLang); len(corpus.examples("perro", 10)) != 1 {
+		t.Errorf("reimported corpus has %d pairs, want 1", len(corpus.Pairs))
+	}
+}
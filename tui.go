@@ -2,9 +2,11 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/goccy/go-yaml"
 )
@@ -13,21 +15,22 @@ type appMode int
 
 const (
 	modeChat appMode = iota
+	modeTutor
 )
 
 type model struct {
 	mode         appMode
 	chat         chatModel
-	wr           *WordReference
+	tutor        tutorModel
 	windowWidth  int
 	windowHeight int
 }
 
-func newModel(wr *WordReference) model {
+func newModel(lookup *wordLookup, api apiClients) model {
 	return model{
-		mode: modeChat,
-		chat: newChatModel(wr),
-		wr:   wr,
+		mode:  modeChat,
+		chat:  newChatModel(lookup, api),
+		tutor: newTutorModel(api),
 	}
 }
 
@@ -41,20 +44,74 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.windowWidth = msg.Width
 		m.windowHeight = msg.Height
 		m.chat.setWidth(msg.Width)
+		m.tutor.setWidth(msg.Width)
 		return m, nil
 	case tea.KeyMsg:
 		if msg.String() == "ctrl+c" {
 			return m, tea.Quit
 		}
+	case switchModeMsg:
+		return m.switchMode(msg.mode)
+	case lookupWordMsg:
+		// Lookups requested from the tutor are shown in chat, where the
+		// result can be turned into cards.
+		m, switchCmd := m.switchMode(modeChat)
+		var cmd tea.Cmd
+		m.chat, cmd = m.chat.update(msg)
+		return m, tea.Batch(switchCmd, cmd)
+	case tutorReplyMsg:
+		var cmd tea.Cmd
+		m.tutor, cmd = m.tutor.update(msg)
+		return m, cmd
+	case spinner.TickMsg:
+		// Both views may be busy regardless of which one is shown.
+		var chatCmd, tutorCmd tea.Cmd
+		m.chat, chatCmd = m.chat.update(msg)
+		m.tutor, tutorCmd = m.tutor.update(msg)
+		return m, tea.Batch(chatCmd, tutorCmd)
 	}
 
-	var cmd tea.Cmd
-	m.chat, cmd = m.chat.update(msg)
-	return m, cmd
+	// Keys go to the visible view. Everything else, such as chat command
+	// results and cursor blinks, goes to both; each ignores what is not its own.
+	if _, isKey := msg.(tea.KeyMsg); isKey {
+		var cmd tea.Cmd
+		if m.mode == modeTutor {
+			m.tutor, cmd = m.tutor.update(msg)
+		} else {
+			m.chat, cmd = m.chat.update(msg)
+		}
+		return m, cmd
+	}
+	var chatCmd, tutorCmd tea.Cmd
+	m.chat, chatCmd = m.chat.update(msg)
+	m.tutor, tutorCmd = m.tutor.update(msg)
+	return m, tea.Batch(chatCmd, tutorCmd)
+}
+
+func (m model) switchMode(mode appMode) (model, tea.Cmd) {
+	if m.mode == mode {
+		return m, nil
+	}
+	m.mode = mode
+	switch mode {
+	case modeTutor:
+		m.chat.textInput.Blur()
+		m.tutor.deadKeys = m.chat.deadKeys // /deadkeys in the chat sets both
+		banner := tutorStyle.Render("Tutor mode") + dimStyle.Render(" — chat in Spanish, esc or /exit to return")
+		return m, tea.Batch(tea.Println(banner), m.tutor.textInput.Focus())
+	default:
+		m.tutor.textInput.Blur()
+		return m, m.chat.textInput.Focus()
+	}
 }
 
 func (m model) View() string {
-	return m.chat.view()
+	switch m.mode {
+	case modeTutor:
+		return m.tutor.view()
+	default:
+		return m.chat.view()
+	}
 }
 
 // Message types
@@ -62,6 +119,11 @@ func (m model) View() string {
 type translateResultMsg struct {
 	word        string
 	translation *Translation
+	dir         direction       // direction the word was translated in
+	restored    string          // set when word was found with accents restored
+	lemma       *LemmaCandidate // set when word was resolved to a lemma
+	suggestions []string        // spelling suggestions when the lookup failed
+	storeErr    error           // lookup history could not be saved
 	err         error
 }
 
@@ -76,8 +138,9 @@ type listTemplatesResultMsg struct {
 }
 
 type addCardResultMsg struct {
-	count int
-	err   error
+	count    int
+	storeErr error // cards were created but not recorded as known
+	err      error
 }
 
 type editorFinishedMsg struct {
@@ -90,88 +153,365 @@ type phrasesResultMsg struct {
 	err     error
 }
 
+type usageResultMsg struct {
+	report usageReport
+	err    error
+}
+
+type mineResultMsg struct {
+	words []minedWord
+	err   error
+}
+
+type pasteFinishedMsg struct {
+	content string
+	err     error
+}
+
+type batchTranslateResultMsg struct {
+	results []translateResultMsg
+}
+
+type bookIngestedMsg struct {
+	book *book
+	err  error
+}
+
+type listBooksResultMsg struct {
+	books []*book
+	err   error
+}
+
+type bookExamplesMsg struct {
+	word     string
+	examples []bookExample
+	err      error
+}
+
+type tatoebaImportedMsg struct {
+	count int
+	err   error
+}
+
+type tatoebaExamplesMsg struct {
+	word    string
+	phrases []Phrase
+	err     error
+}
+
+type conjugationResultMsg struct {
+	conj     *Conjugation
+	selected []int
+	err      error
+}
+
+type switchModeMsg struct {
+	mode appMode
+}
+
+type lookupWordMsg struct {
+	word string
+}
+
+type tutorReplyMsg struct {
+	reply string
+	err   error
+}
+
+type mnemonicResultMsg struct {
+	index    int
+	mnemonic string
+	err      error
+}
+
 // Async commands
 
-func translateCmd(wr *WordReference, word string) tea.Cmd {
+func translateCmd(l *wordLookup, word string) tea.Cmd {
+	return func() tea.Msg {
+		res, err := l.Lookup(word)
+		if err != nil {
+			return translateResultMsg{word: word, err: err, suggestions: suggestionsFor(l.provider(res.dir), word, err)}
+		}
+		lang := "en"
+		if res.dir == dirReverse {
+			lang = "es"
+		}
+		storeErr := rememberLookup(word, lang)
+		return translateResultMsg{word: word, translation: res.translation, dir: res.dir, restored: res.restored, lemma: res.lemma, storeErr: storeErr}
+	}
+}
+
+func batchTranslateCmd(l *wordLookup, words []string) tea.Cmd {
+	return func() tea.Msg {
+		var results []translateResultMsg
+		for _, word := range words {
+			res, err := l.Lookup(word)
+			results = append(results, translateResultMsg{word: word, translation: res.translation, dir: res.dir, restored: res.restored, lemma: res.lemma, err: err})
+		}
+		return batchTranslateResultMsg{results: results}
+	}
+}
+
+// Mines text for unknown words, skipping words in the local store and, when
+// MOCHI_KEY is set, words already on cards in the default deck.
+func mineCmd(api apiClients, load func() (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		text, err := load()
+		if err != nil {
+			return mineResultMsg{err: err}
+		}
+		vs, err := openVocabStore()
+		if err != nil {
+			return mineResultMsg{err: fmt.Errorf("open vocabulary store: %w", err)}
+		}
+		inDeck := map[string]bool{}
+		if key, _ := os.LookupEnv("MOCHI_KEY"); key != "" {
+			if inDeck, err = deckWords(api.mochi(), defaultDeckID); err != nil {
+				return mineResultMsg{err: fmt.Errorf("list deck cards: %w", err)}
+			}
+		}
+		words := mineText(text, func(w string) bool {
+			return inDeck[w] || vs.Has(w)
+		})
+		return mineResultMsg{words: words}
+	}
+}
+
+func ingestBookCmd(file string) tea.Cmd {
+	return func() tea.Msg {
+		b, err := ingestBook(file)
+		return bookIngestedMsg{book: b, err: err}
+	}
+}
+
+func listBooksCmd() tea.Cmd {
+	return func() tea.Msg {
+		books, err := loadBooks()
+		return listBooksResultMsg{books: books, err: err}
+	}
+}
+
+func bookExamplesCmd(word string) tea.Cmd {
+	return func() tea.Msg {
+		books, err := loadBooks()
+		if err != nil {
+			return bookExamplesMsg{word: word, err: err}
+		}
+		return bookExamplesMsg{word: word, examples: findBookExamples(books, word, 10)}
+	}
+}
+
+func importTatoebaCmd(sentencesFile, linksFile string) tea.Cmd {
+	return func() tea.Msg {
+		count, err := importTatoeba(sentencesFile, linksFile, tatoebaSourceLang, tatoebaTargetLang)
+		return tatoebaImportedMsg{count: count, err: err}
+	}
+}
+
+func tatoebaExamplesCmd(word string) tea.Cmd {
+	return func() tea.Msg {
+		corpus, err := loadTatoeba(tatoebaSourceLang, tatoebaTargetLang)
+		if err != nil {
+			return tatoebaExamplesMsg{word: word, err: err}
+		}
+		return tatoebaExamplesMsg{word: word, phrases: corpus.examples(word, 10)}
+	}
+}
+
+func conjugateCmd(c Conjugator, verb string, selected []int) tea.Cmd {
+	return func() tea.Msg {
+		conj, err := c.Conjugate(verb)
+		return conjugationResultMsg{conj: conj, selected: selected, err: err}
+	}
+}
+
+// Creates drill cards in the drill deck: one per tense and person, or one
+// per tense when wholeTense is set.
+func createDrillCardsCmd(api apiClients, conj *Conjugation, tenses []ConjTense, persons []string, wholeTense bool) tea.Cmd {
 	return func() tea.Msg {
-		translation, err := wr.Translate(word)
-		return translateResultMsg{word: word, translation: translation, err: err}
+		deckID := drillDeckID()
+		var cards []Card
+		if wholeTense {
+			for _, tense := range tenses {
+				cards = append(cards, generateTenseCard(deckID, conj, tense))
+			}
+		} else {
+			cards = generateDrillCards(deckID, conj, tenses, persons)
+		}
+		if len(cards) == 0 {
+			return addCardResultMsg{err: fmt.Errorf("no forms match the chosen persons")}
+		}
+
+		mc := api.mochi()
+		count := 0
+		for _, card := range cards {
+			if _, err := mc.CreateCard(card); err != nil {
+				return addCardResultMsg{count: count, err: fmt.Errorf("failed to create card: %w", err)}
+			}
+			count++
+		}
+		return addCardResultMsg{count: count}
 	}
 }
 
-func listDecksCmd() tea.Cmd {
+func listDecksCmd(api apiClients) tea.Cmd {
 	return func() tea.Msg {
-		key, _ := os.LookupEnv("MOCHI_KEY")
-		mc := NewMochiClient(key)
-		decks, err := mc.ListDecks()
+		decks, err := api.mochi().ListDecks()
 		return listDecksResultMsg{decks: decks, err: err}
 	}
 }
 
-func listTemplatesCmd() tea.Cmd {
+func listTemplatesCmd(api apiClients) tea.Cmd {
 	return func() tea.Msg {
-		key, _ := os.LookupEnv("MOCHI_KEY")
-		mc := NewMochiClient(key)
-		templates, err := mc.ListTemplates()
+		templates, err := api.mochi().ListTemplates()
 		return listTemplatesResultMsg{templates: templates, err: err}
 	}
 }
 
-func phrasesCmd(entry ParsedEntry) tea.Cmd {
+func phrasesCmd(api apiClients, entry ParsedEntry, fresh bool) tea.Cmd {
+	return func() tea.Msg {
+		result, err := generatePhrases(api, entry, fresh)
+		return phrasesResultMsg{phrases: result, err: err}
+	}
+}
+
+// Asks the LLM for example sentences using entry's word in its meaning, as
+// a "- <Spanish> — <English>" list for parsePhrases.
+func generatePhrases(api apiClients, entry ParsedEntry, fresh bool) (string, error) {
+	client := api.openAI()
+	systemPrompt := "You are a language learning assistant. Generate 5 short example sentences in Spanish that use the given word with the given meaning. Include an English translation for each. Wrap the target word/phrase in the Spanish sentence with **asterisks** for emphasis. Format each as: `- <Spanish sentence> — <English translation>`"
+	meanings := strings.Join(Map(entry.ToWords, func(tw ToWord) string {
+		return tw.Meaning
+	}), ", ")
+	userPrompt := fmt.Sprintf("Word: %s\nMeaning: %s", entry.FromWord.Source, meanings)
+	return client.CachedChatCompletion(purposePhrases, systemPrompt, userPrompt, fresh)
+}
+
+func mnemonicCmd(api apiClients, index int, entry ParsedEntry) tea.Cmd {
 	return func() tea.Msg {
-		client := NewOpenAIClient()
-		systemPrompt := "You are a language learning assistant. Generate 5 short example sentences in Spanish that use the given word with the given meaning. Include an English translation for each. Wrap the target word/phrase in the Spanish sentence with **asterisks** for emphasis. Format each as: `- <Spanish sentence> — <English translation>`"
+		client := api.openAI()
+		systemPrompt := "You are a language learning assistant helping a learner remember a Spanish word they keep forgetting. Reply in English with three short sections, each a single line: `Mnemonic: <a vivid memory hook>`, `Cognates: <related English or Romance-language words, or none>`, `Etymology: <brief origin of the word>`."
 		meanings := strings.Join(Map(entry.ToWords, func(tw ToWord) string {
 			return tw.Meaning
 		}), ", ")
 		userPrompt := fmt.Sprintf("Word: %s\nMeaning: %s", entry.FromWord.Source, meanings)
-		result, err := client.ChatCompletion(systemPrompt, userPrompt)
-		return phrasesResultMsg{phrases: result, err: err}
+		result, err := client.ChatCompletion(purposeMnemonic, systemPrompt, userPrompt)
+		return mnemonicResultMsg{index: index, mnemonic: strings.TrimSpace(result), err: err}
+	}
+}
+
+func switchModeCmd(mode appMode) tea.Cmd {
+	return func() tea.Msg {
+		return switchModeMsg{mode: mode}
+	}
+}
+
+func lookupWordCmd(word string) tea.Cmd {
+	return func() tea.Msg {
+		return lookupWordMsg{word: word}
+	}
+}
+
+func tutorReplyCmd(api apiClients, history []chatMessage) tea.Cmd {
+	messages := append([]chatMessage{{Role: "system", Content: tutorSystemPrompt}}, history...)
+	return func() tea.Msg {
+		client := api.openAI()
+		reply, err := client.Chat(purposeTutor, messages)
+		return tutorReplyMsg{reply: reply, err: err}
+	}
+}
+
+func usageCmd() tea.Cmd {
+	return func() tea.Msg {
+		report, err := llmUsage.report()
+		return usageResultMsg{report: report, err: err}
 	}
 }
 
-func createPhraseCardsCmd(phrases []Phrase) tea.Cmd {
+func createPhraseCardsCmd(api apiClients, phrases []Phrase, kind cardKind) tea.Cmd {
 	return func() tea.Msg {
-		key, _ := os.LookupEnv("MOCHI_KEY")
-		mc := NewMochiClient(key)
+		mc := api.mochi()
 		count := 0
+		var storeErr error
 		for _, p := range phrases {
-			tmpl := &EditTemplate{
-				TargetLang:    p.Source,
-				SourceLang:    p.Target,
-				TargetExample: p.Source,
-				SourceExample: p.Target,
+			var cards []Card
+			switch kind {
+			case cardKindCloze:
+				card, ok := generateClozeCard(defaultDeckID, p)
+				if !ok {
+					return addCardResultMsg{count: count, err: fmt.Errorf("no highlighted word to cloze in %q", p.Source)}
+				}
+				cards = []Card{card}
+			default:
+				tmpl := &EditTemplate{
+					TargetLang:    p.Source,
+					SourceLang:    p.Target,
+					TargetExample: p.Source,
+					SourceExample: p.Target,
+				}
+				cards = generateCards(defaultDeckID, tmpl)
 			}
-			cards := generateCards(defaultDeckID, tmpl)
 			for _, card := range cards {
 				if _, err := mc.CreateCard(card); err != nil {
 					return addCardResultMsg{count: count, err: fmt.Errorf("failed to create card: %w", err)}
 				}
 				count++
 			}
+			if err := rememberWords(p.Highlights...); err != nil {
+				storeErr = err
+			}
 		}
-		return addCardResultMsg{count: count}
+		return addCardResultMsg{count: count, storeErr: storeErr}
 	}
 }
 
-func createCardsCmd(yamlContent string) tea.Cmd {
+func createCardsCmd(api apiClients, yamlContent string) tea.Cmd {
 	return func() tea.Msg {
+		count, storeErr, err := createCardsFromYAML(api, defaultDeckID, yamlContent)
+		return addCardResultMsg{count: count, storeErr: storeErr, err: err}
+	}
+}
+
+// Decodes every EditTemplate document in yamlContent, as /add writes one per
+// entry.
+func decodeTemplates(yamlContent string) ([]EditTemplate, error) {
+	var templates []EditTemplate
+	dec := yaml.NewDecoder(strings.NewReader(yamlContent))
+	for {
 		var tmpl EditTemplate
-		dec := yaml.NewDecoder(strings.NewReader(yamlContent))
-		if err := dec.Decode(&tmpl); err != nil {
-			return addCardResultMsg{err: fmt.Errorf("invalid yaml: %w", err)}
+		if err := dec.Decode(&tmpl); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid yaml: %w", err)
 		}
+		templates = append(templates, tmpl)
+	}
+	if len(templates) == 0 {
+		return nil, fmt.Errorf("no cards in yaml")
+	}
+	return templates, nil
+}
 
-		cards := generateCards(defaultDeckID, &tmpl)
-		key, _ := os.LookupEnv("MOCHI_KEY")
-		mc := NewMochiClient(key)
-		count := 0
-		for _, card := range cards {
+// Creates the forward and reverse cards for every EditTemplate document in
+// yamlContent and remembers their words as known. storeErr reports words
+// that could not be remembered after their cards were created.
+func createCardsFromYAML(api apiClients, deckID, yamlContent string) (count int, storeErr, err error) {
+	templates, err := decodeTemplates(yamlContent)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	mc := api.mochi()
+	for _, tmpl := range templates {
+		for _, card := range generateCards(deckID, &tmpl) {
 			if _, err := mc.CreateCard(card); err != nil {
-				return addCardResultMsg{count: count, err: fmt.Errorf("failed to create card: %w", err)}
+				return count, storeErr, fmt.Errorf("failed to create card: %w", err)
 			}
 			count++
 		}
-		return addCardResultMsg{count: count}
+		if err := rememberWords(append([]string{tmpl.TargetLang}, strings.Split(tmpl.SourceLang, "\n")...)...); err != nil {
+			storeErr = err
+		}
 	}
+	return count, storeErr, nil
 }
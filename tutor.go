@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const tutorSystemPrompt = "You are a friendly Spanish tutor having a casual conversation with a learner. " +
+	"Always reply in Spanish, keeping replies to a few sentences. " +
+	"If the learner's last message had mistakes, start your reply with a line `Corrección: <corrected sentence>` " +
+	"before continuing the conversation. Wrap every corrected word and any useful vocabulary in **asterisks**."
+
+type tutorModel struct {
+	textInput textinput.Model
+	spinner   spinner.Model
+	history   []chatMessage // whole conversation, excluding the system prompt
+	words     []string      // highlighted words from the last reply, for quick lookup
+	api       apiClients
+	deadKeys  bool // the chat's /deadkeys setting, copied on entering tutor mode
+	width     int
+	busy      bool
+}
+
+func newTutorModel(api apiClients) tutorModel {
+	ti := textinput.New()
+	ti.Placeholder = "Escribe en español… (/exit to return, alt+1-9 to look up a word)"
+	ti.Prompt = "✎ "
+	ti.PromptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#70b950"))
+	ti.CharLimit = 512
+
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("#70b950"))
+
+	return tutorModel{
+		textInput: ti,
+		spinner:   s,
+		api:       api,
+	}
+}
+
+func (m *tutorModel) setWidth(width int) {
+	m.width = width
+	m.textInput.Width = width - 4
+}
+
+func (m *tutorModel) setBusy(busy bool) tea.Cmd {
+	m.busy = busy
+	if busy {
+		m.textInput.Blur()
+		return m.spinner.Tick
+	}
+	m.textInput.Focus()
+	return nil
+}
+
+func (m tutorModel) update(msg tea.Msg) (tutorModel, tea.Cmd) {
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		key := msg.String()
+		if n, ok := strings.CutPrefix(key, "alt+"); ok {
+			if i, err := strconv.Atoi(n); err == nil && i >= 1 && i <= len(m.words) {
+				return m, lookupWordCmd(m.words[i-1])
+			}
+		}
+		switch key {
+		case "esc":
+			return m, switchModeCmd(modeChat)
+		case "enter":
+			if m.busy {
+				return m, nil
+			}
+			input := strings.TrimSpace(m.textInput.Value())
+			if input == "" {
+				return m, nil
+			}
+			m.textInput.Reset()
+			if strings.HasPrefix(input, "/") {
+				return m, m.handleCommand(input)
+			}
+			m.history = append(m.history, chatMessage{Role: "user", Content: input})
+			cmds = append(cmds, tea.Println(tutorUserStyle.Render("tú › ")+input))
+			cmds = append(cmds, m.setBusy(true), tutorReplyCmd(m.api, m.history))
+			return m, tea.Batch(cmds...)
+		}
+
+	case tutorReplyMsg:
+		m.setBusy(false)
+		if msg.err != nil {
+			// Drop the unanswered turn so it can be retried.
+			m.history = m.history[:len(m.history)-1]
+			return m, tea.Println(errStyle.Render("Error: " + msg.err.Error()))
+		}
+		m.history = append(m.history, chatMessage{Role: "assistant", Content: msg.reply})
+		m.words = tutorWords(msg.reply)
+		return m, tea.Println(renderTutorReply(msg.reply, m.words))
+
+	case spinner.TickMsg:
+		if m.busy {
+			var cmd tea.Cmd
+			m.spinner, cmd = m.spinner.Update(msg)
+			return m, cmd
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.textInput, cmd = m.textInput.Update(msg)
+	if key, ok := msg.(tea.KeyMsg); ok && key.Type == tea.KeyRunes && m.deadKeys {
+		applyDeadKeys(&m.textInput)
+	}
+	return m, cmd
+}
+
+func (m *tutorModel) handleCommand(input string) tea.Cmd {
+	parts := strings.Fields(input)
+	switch parts[0] {
+	case "/exit", "/chat":
+		return switchModeCmd(modeChat)
+	case "/look", "/l":
+		if len(parts) < 2 {
+			return tea.Println(errStyle.Render("Usage: /look <n|word>"))
+		}
+		if n, err := strconv.Atoi(parts[1]); err == nil {
+			if n < 1 || n > len(m.words) {
+				return tea.Println(errStyle.Render(fmt.Sprintf("Invalid index: %d (must be 1-%d)", n, len(m.words))))
+			}
+			return lookupWordCmd(m.words[n-1])
+		}
+		return lookupWordCmd(strings.Join(parts[1:], " "))
+	case "/reset":
+		m.history = nil
+		m.words = nil
+		return tea.Println(dimStyle.Render("Conversation cleared."))
+	default:
+		return tea.Println(errStyle.Render(fmt.Sprintf("Unknown command: %s (tutor mode has /look, /reset, /exit)", parts[0])))
+	}
+}
+
+func (m tutorModel) view() string {
+	if m.busy {
+		return m.spinner.View() + dimStyle.Render("Tutor is typing...")
+	}
+	hint := "esc to return"
+	if len(m.words) > 0 {
+		hint = fmt.Sprintf("alt+1-%d look up · %s", len(m.words), hint)
+	}
+	return m.textInput.View() + "\n" + dimStyle.Render(hint)
+}
+
+// Returns the distinct highlighted words of a tutor reply in order.
+func tutorWords(reply string) []string {
+	var words []string
+	seen := map[string]bool{}
+	for _, m := range highlightRe.FindAllStringSubmatch(reply, -1) {
+		w := strings.ToLower(strings.Trim(m[1], ".,;:!?¡¿\"'"))
+		if w == "" || seen[w] {
+			continue
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	return words
+}
+
+var (
+	tutorUserStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a")).Bold(true)
+	tutorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#70b950")).Bold(true)
+)
+
+func renderTutorReply(reply string, words []string) string {
+	wordStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a")).Bold(true)
+	correctionStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ff8855"))
+	idxStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#555555")).Bold(true)
+
+	var sb strings.Builder
+	sb.WriteString(tutorStyle.Render("tutor › "))
+	for i, line := range strings.Split(strings.TrimSpace(reply), "\n") {
+		if i > 0 {
+			sb.WriteString("\n        ")
+		}
+		line = highlightRe.ReplaceAllStringFunc(line, func(s string) string {
+			return wordStyle.Render(strings.Trim(s, "*"))
+		})
+		if strings.HasPrefix(line, "Corrección:") {
+			line = correctionStyle.Render(line)
+		}
+		sb.WriteString(line)
+	}
+	if len(words) > 0 {
+		var refs []string
+		for i, w := range words {
+			refs = append(refs, idxStyle.Render(fmt.Sprintf("%d.", i+1))+" "+w)
+		}
+		sb.WriteString("\n        " + dimStyle.Render(strings.Join(refs, "  ")))
+	}
+	return sb.String()
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// USD per million tokens, input and output.
+var modelPricing = map[string]struct{ Input, Output float64 }{
+	"gpt-4o-mini": {0.15, 0.60},
+	"gpt-4o":      {2.50, 10.00},
+	"gpt-4.1":     {2.00, 8.00},
+}
+
+// Purposes recorded alongside each LLM call.
+const (
+	purposePhrases  = "phrases"
+	purposeMnemonic = "mnemonic"
+	purposeVerify   = "verification"
+	purposeTutor    = "tutor"
+)
+
+type usageRecord struct {
+	Time             time.Time `json:"time"`
+	Model            string    `json:"model"`
+	Purpose          string    `json:"purpose"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	Cost             float64   `json:"cost"`
+}
+
+func costFor(model string, promptTokens, completionTokens int) float64 {
+	price, ok := modelPricing[model]
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1_000_000
+}
+
+type usageTotals struct {
+	Calls            int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+}
+
+func (t *usageTotals) add(r usageRecord) {
+	t.Calls++
+	t.PromptTokens += r.PromptTokens
+	t.CompletionTokens += r.CompletionTokens
+	t.Cost += r.Cost
+}
+
+// usageLedger keeps the records for this session in memory and appends every
+// record to usage.jsonl in the data directory so monthly totals survive
+// restarts.
+type usageLedger struct {
+	mu        sync.Mutex
+	session   []usageRecord
+	budget    float64 // monthly cap in USD, 0 for none
+	budgetErr error   // invalid OPENAI_MONTHLY_BUDGET, reported on every call
+}
+
+// Shared by every OpenAIClient in the process.
+var llmUsage = newUsageLedger()
+
+func newUsageLedger() *usageLedger {
+	budget, err := budgetFromEnv()
+	return &usageLedger{budget: budget, budgetErr: err}
+}
+
+func budgetFromEnv() (float64, error) {
+	v := os.Getenv("OPENAI_MONTHLY_BUDGET")
+	if v == "" {
+		return 0, nil
+	}
+	budget, err := strconv.ParseFloat(v, 64)
+	if err != nil || budget < 0 {
+		return 0, fmt.Errorf("invalid OPENAI_MONTHLY_BUDGET %q: want a dollar amount such as 5 or 2.50", v)
+	}
+	return budget, nil
+}
+
+func (l *usageLedger) record(r usageRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.session = append(l.session, r)
+
+	path, err := dataPath("usage.jsonl")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(r)
+}
+
+// Returns all persisted records from the month containing now.
+func (l *usageLedger) month(now time.Time) ([]usageRecord, error) {
+	path, err := dataPath("usage.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []usageRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r usageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		if r.Time.Year() == now.Year() && r.Time.Month() == now.Month() {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// Returns an error if this month's spend has reached the budget.
+func (l *usageLedger) checkBudget() error {
+	if l.budgetErr != nil {
+		return l.budgetErr
+	}
+	if l.budget <= 0 {
+		return nil
+	}
+	records, err := l.month(time.Now())
+	if err != nil {
+		return err
+	}
+	var total usageTotals
+	for _, r := range records {
+		total.add(r)
+	}
+	if total.Cost >= l.budget {
+		return fmt.Errorf("monthly LLM budget of $%.2f reached ($%.4f spent)", l.budget, total.Cost)
+	}
+	return nil
+}
+
+type usageReport struct {
+	Session   usageTotals
+	Month     usageTotals
+	ByPurpose map[string]usageTotals
+	Budget    float64
+}
+
+func (l *usageLedger) report() (usageReport, error) {
+	l.mu.Lock()
+	session := append([]usageRecord(nil), l.session...)
+	l.mu.Unlock()
+
+	rep := usageReport{ByPurpose: map[string]usageTotals{}, Budget: l.budget}
+	for _, r := range session {
+		rep.Session.add(r)
+	}
+	records, err := l.month(time.Now())
+	if err != nil {
+		return rep, err
+	}
+	for _, r := range records {
+		rep.Month.add(r)
+		t := rep.ByPurpose[r.Purpose]
+		t.add(r)
+		rep.ByPurpose[r.Purpose] = t
+	}
+	return rep, nil
+}
+
+func renderUsage(rep usageReport) string {
+	headStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a")).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+	numStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#8197bf"))
+
+	line := func(label string, t usageTotals) string {
+		return fmt.Sprintf("  %s %s\n", labelStyle.Render(fmt.Sprintf("%-14s", label)),
+			numStyle.Render(fmt.Sprintf("%3d calls  %7d in  %7d out  $%.4f", t.Calls, t.PromptTokens, t.CompletionTokens, t.Cost)))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(headStyle.Render("LLM usage") + "\n")
+	sb.WriteString(line("This session", rep.Session))
+	sb.WriteString(line("This month", rep.Month))
+
+	purposes := make([]string, 0, len(rep.ByPurpose))
+	for p := range rep.ByPurpose {
+		purposes = append(purposes, p)
+	}
+	sort.Strings(purposes)
+	for _, p := range purposes {
+		sb.WriteString(line("  "+p, rep.ByPurpose[p]))
+	}
+	if rep.Budget > 0 {
+		sb.WriteString(fmt.Sprintf("  %s %s\n", labelStyle.Render(fmt.Sprintf("%-14s", "Budget")),
+			numStyle.Render(fmt.Sprintf("$%.4f of $%.2f used", rep.Month.Cost, rep.Budget))))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
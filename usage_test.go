@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// Starts a stand-in for the chat completions API that always answers reply,
+// and points NewOpenAIClient at it. The returned counter tracks the calls.
+func startFakeOpenAI(t *testing.T, reply string) *atomic.Int32 {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		writeJSON(w, map[string]any{
+			"choices": []any{map[string]any{"message": map[string]string{"content": fmt.Sprintf("%s #%d", reply, n)}}},
+			"usage":   map[string]int{"prompt_tokens": 10, "completion_tokens": 5},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	t.Setenv("OPENAI_BASE_URL", srv.URL)
+	t.Setenv("OPENAI_API_KEY", "test-key")
+	return &calls
+}
+
+func TestChatKeepsReplyWhenLedgerFails(t *testing.T) {
+	startFakeOpenAI(t, "hola")
+	// A file where the data directory should be makes every write fail.
+	blocker := filepath.Join(t.TempDir(), "home")
+	if err := os.WriteFile(blocker, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ANKIBUILDER_HOME", filepath.Join(blocker, "data"))
+
+	reply, err := NewOpenAIClient(nil).ChatCompletion(purposeTutor, "system", "user")
+	if err != nil || reply != "hola #1" {
+		t.Errorf("got %q, %v; want the reply despite the ledger error", reply, err)
+	}
+}
+
+func TestBudgetFromEnv(t *testing.T) {
+	for _, c := range []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"2.50", 2.5, false},
+		{"$5", 0, true},
+		{"-1", 0, true},
+	} {
+		t.Setenv("OPENAI_MONTHLY_BUDGET", c.value)
+		got, err := budgetFromEnv()
+		if got != c.want || (err != nil) != c.wantErr {
+			t.Errorf("budgetFromEnv(%q) = %v, %v", c.value, got, err)
+		}
+	}
+
+	t.Setenv("OPENAI_MONTHLY_BUDGET", "five")
+	if err := newUsageLedger().checkBudget(); err == nil || !strings.Contains(err.Error(), "OPENAI_MONTHLY_BUDGET") {
+		t.Errorf("checkBudget with an invalid budget gave %v", err)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const verifySystemPrompt = "You are a meticulous Spanish teacher reviewing flashcards before a student studies them. " +
+	"Check that the Spanish and English sides mean the same thing, that any example sentence uses the word in the given sense, " +
+	"and that both languages are grammatical. Reply with JSON only, no code fences: " +
+	`{"ok": true|false, "issues": ["<short description of each problem>"]}`
+
+// verification is the LLM's verdict for a single card draft.
+type verification struct {
+	Label  string   `json:"-"`
+	OK     bool     `json:"ok"`
+	Issues []string `json:"issues"`
+}
+
+// verifyDraft is the card content sent for checking, independent of whether
+// it came from an EditTemplate or a Phrase.
+type verifyDraft struct {
+	Label  string
+	Prompt string
+}
+
+// Describes a card template for the verifier. /add puts the looked-up word
+// and its example in TargetLang and SourceExample, and the translation in
+// SourceLang and TargetExample, so fromLang and toLang name those sides.
+func draftFromTemplate(tmpl *EditTemplate, fromLang, toLang string) verifyDraft {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s\n%s: %s\n", fromLang, tmpl.TargetLang, toLang, tmpl.SourceLang)
+	if tmpl.SourceExample != "" {
+		fmt.Fprintf(&sb, "%s example: %s\n", fromLang, tmpl.SourceExample)
+	}
+	if tmpl.TargetExample != "" {
+		fmt.Fprintf(&sb, "%s example: %s\n", toLang, tmpl.TargetExample)
+	}
+	return verifyDraft{Label: tmpl.TargetLang, Prompt: sb.String()}
+}
+
+func draftFromPhrase(p Phrase) verifyDraft {
+	prompt := fmt.Sprintf("Spanish sentence: %s\nEnglish translation: %s\n", p.Source, p.Target)
+	if len(p.Highlights) > 0 {
+		prompt += fmt.Sprintf("Target word: %s\n", strings.Join(p.Highlights, ", "))
+	}
+	return verifyDraft{Label: p.Source, Prompt: prompt}
+}
+
+func verifyDrafts(client *OpenAIClient, drafts []verifyDraft) ([]verification, error) {
+	var results []verification
+	for _, d := range drafts {
+		raw, err := client.ChatCompletion(purposeVerify, verifySystemPrompt, d.Prompt)
+		if err != nil {
+			return nil, fmt.Errorf("verify %q: %w", d.Label, err)
+		}
+		v, err := parseVerification(raw)
+		if err != nil {
+			return nil, fmt.Errorf("verify %q: %w", d.Label, err)
+		}
+		v.Label = d.Label
+		results = append(results, v)
+	}
+	return results, nil
+}
+
+func parseVerification(raw string) (verification, error) {
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+	var v verification
+	if err := json.Unmarshal([]byte(strings.TrimSpace(raw)), &v); err != nil {
+		return v, fmt.Errorf("parse verification: %w", err)
+	}
+	if len(v.Issues) > 0 {
+		v.OK = false
+	}
+	return v, nil
+}
+
+type verifyResultMsg struct {
+	results []verification
+	create  tea.Cmd // card creation to run once the user accepts
+	err     error
+}
+
+func verifyTemplateCmd(api apiClients, yamlContent, fromLang, toLang string) tea.Cmd {
+	return func() tea.Msg {
+		templates, err := decodeTemplates(yamlContent)
+		if err != nil {
+			return verifyResultMsg{err: err}
+		}
+		drafts := Map(templates, func(tmpl EditTemplate) verifyDraft {
+			return draftFromTemplate(&tmpl, fromLang, toLang)
+		})
+		results, err := verifyDrafts(api.openAI(), drafts)
+		return verifyResultMsg{results: results, create: createCardsCmd(api, yamlContent), err: err}
+	}
+}
+
+func verifyPhrasesCmd(api apiClients, phrases []Phrase, kind cardKind) tea.Cmd {
+	return func() tea.Msg {
+		results, err := verifyDrafts(api.openAI(), Map(phrases, draftFromPhrase))
+		return verifyResultMsg{results: results, create: createPhraseCardsCmd(api, phrases, kind), err: err}
+	}
+}
+
+func renderVerifications(results []verification) string {
+	okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#70b950"))
+	badStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ff5555")).Bold(true)
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#ffffff"))
+	issueStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("#fad07a"))
+
+	var sb strings.Builder
+	for _, v := range results {
+		if v.OK {
+			sb.WriteString(fmt.Sprintf("  %s %s\n", okStyle.Render("✓"), labelStyle.Render(v.Label)))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("  %s %s\n", badStyle.Render("✗"), labelStyle.Render(v.Label)))
+		for _, issue := range v.Issues {
+			sb.WriteString(fmt.Sprintf("      %s\n", issueStyle.Render("• "+issue)))
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDraftFromTemplateLabelsSides(t *testing.T) {
+	yml := "TargetLang: dog (n)\nSourceLang: el perro (nm)\nSourceExample: The dog barks.\nTargetExample: El perro ladra.\n" +
+		"---\nTargetLang: cat (n)\nSourceLang: el gato (nm)\n"
+	templates, err := decodeTemplates(yml)
+	if err != nil || len(templates) != 2 {
+		t.Fatalf("decoded %d templates, err %v; want 2", len(templates), err)
+	}
+	prompt := draftFromTemplate(&templates[0], "English", "Spanish").Prompt
+	for _, want := range []string{"English: dog (n)", "Spanish: el perro (nm)", "English example: The dog barks.", "Spanish example: El perro ladra."} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, prompt)
+		}
+	}
+	if _, err := decodeTemplates(""); err == nil {
+		t.Error("empty yaml decoded without error")
+	}
+}
+
+func TestChatKeepsCardsWhenVerificationFails(t *testing.T) {
+	fake, _ := startFakeMochi(t)
+	d := newChatDriver(t, nil)
+
+	yml := "TargetLang: dog (n)\nSourceLang: el perro (nm)\n"
+	d.send(verifyTemplateCmd(d.m.api, yml, "English", "Spanish")())
+	d.expectOutput("Could not verify the cards:", "OPENAI_API_KEY is not set", "/confirm to create anyway")
+	if d.m.pendingCreate == nil {
+		t.Fatal("failed verification dropped the edited cards")
+	}
+
+	d.typeLine("/confirm")
+	d.settle()
+	d.expectOutput("Successfully created 2 card(s).")
+	if got := len(fake.Cards()); got != 2 {
+		t.Errorf("created %d cards, want 2", got)
+	}
+}
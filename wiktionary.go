@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Wiktionary looks words up in a local kaikki.org JSONL extract, one entry
+// per line. An offset index of the file is built on first use.
+type Wiktionary struct {
+	Path string
+	// kaikki.org publishes one extract per language with English glosses,
+	// so an extract only translates from its language into English.
+	FromLang string
+	ToLang   string
+
+	once    sync.Once
+	offsets map[string][]int64  // lowercase word -> line offsets
+	near    map[string][]string // nearKey -> lowercase words
+	err     error
+}
+
+type kaikkiEntry struct {
+	Word   string `json:"word"`
+	POS    string `json:"pos"`
+	Senses []struct {
+		Glosses  []string `json:"glosses"`
+		Tags     []string `json:"tags"`
+		Examples []struct {
+			Text        string `json:"text"`
+			English     string `json:"english"`
+			Translation string `json:"translation"`
+		} `json:"examples"`
+	} `json:"senses"`
+	HeadTemplates []struct {
+		Expansion string `json:"expansion"`
+	} `json:"head_templates"`
+}
+
+func NewWiktionary(path string) (*Wiktionary, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return &Wiktionary{Path: path, FromLang: "Spanish", ToLang: "English"}, nil
+}
+
+// Opens the Spanish extract named by WIKTIONARY_JSONL, or returns nil when
+// it is not set.
+func wiktionaryFromEnv() (*Wiktionary, error) {
+	path := os.Getenv("WIKTIONARY_JSONL")
+	if path == "" {
+		return nil, nil
+	}
+	wk, err := NewWiktionary(path)
+	if err != nil {
+		return nil, fmt.Errorf("open wiktionary extract: %w", err)
+	}
+	return wk, nil
+}
+
+func (wk *Wiktionary) buildIndex() {
+	f, err := os.Open(wk.Path)
+	if err != nil {
+		wk.err = err
+		return
+	}
+	defer f.Close()
+
+	wk.offsets = map[string][]int64{}
+	wk.near = map[string][]string{}
+	r := bufio.NewReaderSize(f, 1<<20)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			var head struct {
+				Word string `json:"word"`
+			}
+			if json.Unmarshal(line, &head) == nil && head.Word != "" {
+				key := strings.ToLower(head.Word)
+				if _, ok := wk.offsets[key]; !ok {
+					nk := nearKey(foldAccents(key), utf8.RuneCountInString(key))
+					wk.near[nk] = append(wk.near[nk], key)
+				}
+				wk.offsets[key] = append(wk.offsets[key], offset)
+			}
+			offset += int64(len(line))
+		}
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			wk.err = err
+			return
+		}
+	}
+}
+
+func (wk *Wiktionary) entries(word string) ([]kaikkiEntry, error) {
+	wk.once.Do(wk.buildIndex)
+	if wk.err != nil {
+		return nil, wk.err
+	}
+	offsets := wk.offsets[strings.ToLower(word)]
+	if len(offsets) == 0 {
+		return nil, nil
+	}
+	f, err := os.Open(wk.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []kaikkiEntry
+	for _, off := range offsets {
+		if _, err := f.Seek(off, io.SeekStart); err != nil {
+			return nil, err
+		}
+		line, err := bufio.NewReader(f).ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		var e kaikkiEntry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("parse entry for %s: %w", word, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// Translate returns the word's senses in the same shape as a WordReference
+// lookup, with one section per part of speech.
+func (wk *Wiktionary) Translate(word string) (*Translation, error) {
+	entries, err := wk.entries(word)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no Wiktionary entry for %q", word)
+	}
+
+	translation := &Translation{
+		Word:     word,
+		FromLang: wk.FromLang,
+		ToLang:   wk.ToLang,
+		URL:      "https://en.wiktionary.org/wiki/" + word,
+	}
+	for _, e := range entries {
+		section := TranslationSection{Title: "Wiktionary: " + e.POS, Kind: SectionPrincipal}
+		if e.POS == "phrase" || e.POS == "proverb" {
+			section.Kind = SectionCompound
+		}
+		grammar := kaikkiGrammar(e)
+		for _, sense := range e.Senses {
+			if len(sense.Glosses) == 0 {
+				continue
+			}
+			// Region and register tags describe the Spanish word; the rest
+			// (e.g. "transitive") stay as notes on the gloss.
+			var labels Labels
+			var notes []string
+			for _, tag := range sense.Tags {
+				if !labels.add(tag) {
+					notes = append(notes, tag)
+				}
+			}
+			entry := ParsedEntry{
+				FromWord: FromWord{Source: e.Word, Grammar: grammar, Tags: parseGrammar(grammar + " " + strings.Join(sense.Tags, " "))},
+				ToWords: []ToWord{{
+					Meaning: sense.Glosses[len(sense.Glosses)-1],
+					Notes:   strings.Join(notes, ", "),
+					Grammar: e.POS,
+					Tags:    parseGrammar(e.POS),
+				}},
+				Labels: labels,
+			}
+			if len(sense.Glosses) > 1 {
+				entry.Context = sense.Glosses[0]
+			}
+			for _, ex := range sense.Examples {
+				if entry.FromExample == "" {
+					entry.FromExample = ex.Text
+				}
+				if t := cmp.Or(ex.English, ex.Translation); t != "" {
+					entry.ToExample = append(entry.ToExample, t)
+				}
+			}
+			section.Entries = append(section.Entries, entry)
+		}
+		if len(section.Entries) == 0 {
+			continue
+		}
+		merged := false
+		for i := range translation.Translations {
+			if translation.Translations[i].Title == section.Title {
+				translation.Translations[i].Entries = append(translation.Translations[i].Entries, section.Entries...)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			translation.Translations = append(translation.Translations, section)
+		}
+	}
+	return translation, nil
+}
+
+// Converts kaikki part of speech and gender tags to WordReference-style
+// grammar abbreviations such as "nm" or "adj".
+func kaikkiGrammar(e kaikkiEntry) string {
+	head := ""
+	if len(e.HeadTemplates) > 0 {
+		head = " " + e.HeadTemplates[0].Expansion + " "
+	}
+	switch e.POS {
+	case "noun":
+		switch {
+		case strings.Contains(head, " m or f ") || strings.Contains(head, " mf "):
+			return "nmf"
+		case strings.Contains(head, " m "), strings.Contains(head, " m,"):
+			return "nm"
+		case strings.Contains(head, " f "), strings.Contains(head, " f,"):
+			return "nf"
+		}
+		return "n"
+	case "verb":
+		return "v"
+	case "adv":
+		return "adv"
+	case "adj":
+		return "adj"
+	case "phrase":
+		return "loc"
+	}
+	return e.POS
+}
+
+// WordsNear returns the headwords in the buckets for word's accent-folded
+// first letter and the lengths within two of its own, or nil if the extract
+// could not be read.
+func (wk *Wiktionary) WordsNear(word string) []string {
+	wk.once.Do(wk.buildIndex)
+	if wk.err != nil {
+		return nil
+	}
+	folded := foldAccents(word)
+	n := utf8.RuneCountInString(folded)
+	var words []string
+	for l := max(n-2, 1); l <= n+2; l++ {
+		words = append(words, wk.near[nearKey(folded, l)]...)
+	}
+	return words
+}
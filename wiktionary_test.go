@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testKaikki = `{"word": "perro", "pos": "noun", "lang": "Spanish", "head_templates": [{"expansion": "perro m (plural perros)"}], "senses": [{"glosses": ["dog"], "examples": [{"text": "El perro ladra.", "english": "The dog barks."}]}, {"glosses": ["lazy person"], "tags": ["colloquial", "Mexico"]}]}
+{"word": "casa", "pos": "noun", "lang": "Spanish", "senses": [{"glosses": ["house"]}]}
+`
+
+func openTestWiktionary(t *testing.T) *Wiktionary {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "es.jsonl")
+	if err := os.WriteFile(path, []byte(testKaikki), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WIKTIONARY_JSONL", path)
+	wk, err := wiktionaryFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wk
+}
+
+func TestWiktionaryTranslate(t *testing.T) {
+	wk := openTestWiktionary(t)
+	tr, err := wk.Translate("Perro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.FromLang != "Spanish" || tr.ToLang != "English" {
+		t.Errorf("translation is %s → %s", tr.FromLang, tr.ToLang)
+	}
+	entries := flattenEntries(tr)
+	if len(entries) != 2 || entries[0].ToWords[0].Meaning != "dog" || entries[0].FromExample != "El perro ladra." {
+		t.Fatalf("entries %+v", entries)
+	}
+	if got := entries[1].Labels.String(); !strings.Contains(got, "colloquial") || !strings.Contains(got, "Mexico") {
+		t.Errorf("labels %q", got)
+	}
+	if _, err := wk.Translate("gato"); err == nil {
+		t.Error("missing word translated")
+	}
+}
+
+// Without WordReference the extract serves Spanish input only; English
+// input is refused rather than answered with Spanish entries.
+func TestWiktionaryOnlyServesItsDirection(t *testing.T) {
+	t.Setenv("ANKIBUILDER_HOME", t.TempDir())
+	t.Setenv("ANKIBUILDER_DICT", "")
+	reverse, err := loadProvider(nil, openTestWiktionary(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &wordLookup{reverse: reverse}
+
+	res, err := l.Lookup("casa")
+	if err != nil || res.dir != dirReverse || res.translation.FromLang != "Spanish" {
+		t.Errorf("casa: dir %v, err %v", res.dir, err)
+	}
+	l.dir = dirForward
+	if _, err := l.Lookup("house"); err == nil || !strings.Contains(err.Error(), "no English → Spanish dictionary") {
+		t.Errorf("English lookup without an en→es dictionary gave %v", err)
+	}
+	if pairs := reverse.Pairs(); len(pairs) != 1 || pairs[0].Code != "esen" {
+		t.Errorf("pairs %+v", pairs)
+	}
+}
+
+func TestWiktionaryWordsNear(t *testing.T) {
+	wk := openTestWiktionary(t)
+	if got := wk.WordsNear("pero"); len(got) != 1 || got[0] != "perro" {
+		t.Errorf("words near pero: %q", got)
+	}
+	if got := wk.WordsNear("cosa"); len(got) != 1 || got[0] != "casa" {
+		t.Errorf("words near cosa: %q", got)
+	}
+	if got := wk.WordsNear("gato"); len(got) != 0 {
+		t.Errorf("words near gato: %q", got)
+	}
+
+	broken := &Wiktionary{Path: filepath.Join(t.TempDir(), "missing.jsonl")}
+	if got := broken.WordsNear("perro"); got != nil {
+		t.Errorf("an unreadable extract listed %q", got)
+	}
+}
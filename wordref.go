@@ -1,10 +1,11 @@
 package main
 
 import (
-	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
@@ -16,11 +17,24 @@ const (
 	TRANSLATION_URL = WR_URL + "%s/%s"
 )
 
+// Base URL of WordReference, overridable with WORDREFERENCE_URL to point at
+// a mirror or a test server.
+func wordReferenceURL() string {
+	if u := os.Getenv("WORDREFERENCE_URL"); u != "" {
+		return strings.TrimSuffix(u, "/") + "/"
+	}
+	return WR_URL
+}
+
 type WordReference struct {
 	DictCode  string
 	FromLang  string
 	ToLang    string
 	UserAgent string
+
+	baseURL   string
+	client    *http.Client
+	available map[string]map[string]string // dict code -> from/to labels
 }
 
 type Translation struct {
@@ -31,15 +45,74 @@ type Translation struct {
 	Translations []TranslationSection
 }
 
+// Returns a deep copy of t.
+func (t *Translation) clone() *Translation {
+	c := *t
+	c.Translations = slices.Clone(t.Translations)
+	for i, section := range c.Translations {
+		section.Entries = slices.Clone(section.Entries)
+		for j, entry := range section.Entries {
+			entry.ToWords = slices.Clone(entry.ToWords)
+			for k, tw := range entry.ToWords {
+				tw.Labels = tw.Labels.clone()
+				entry.ToWords[k] = tw
+			}
+			entry.ToExample = slices.Clone(entry.ToExample)
+			entry.Labels = entry.Labels.clone()
+			section.Entries[j] = entry
+		}
+		c.Translations[i] = section
+	}
+	return &c
+}
+
 type TranslationSection struct {
 	Title   string
+	Kind    SectionKind   `json:"kind"`
 	Entries []ParsedEntry `json:"entries"`
 }
 
+// SectionKind classifies a WordReference translation table.
+type SectionKind string
+
+const (
+	SectionPrincipal  SectionKind = "principal"
+	SectionAdditional SectionKind = "additional"
+	SectionCompound   SectionKind = "compound"
+	SectionPhrasal    SectionKind = "phrasal"
+	SectionOther      SectionKind = "other"
+)
+
+var sectionKinds = []SectionKind{SectionPrincipal, SectionAdditional, SectionCompound, SectionPhrasal, SectionOther}
+
+// Maps a table's title attribute, in either language of the page, to its
+// kind.
+func sectionKindFromTitle(title string) SectionKind {
+	t := strings.ToLower(title)
+	switch {
+	case strings.Contains(t, "principal"):
+		return SectionPrincipal
+	case strings.Contains(t, "additional"), strings.Contains(t, "adicional"):
+		return SectionAdditional
+	case strings.Contains(t, "compound"), strings.Contains(t, "compuesta"), strings.Contains(t, "locuci"), strings.Contains(t, "idiom"):
+		return SectionCompound
+	case strings.Contains(t, "phrasal"):
+		return SectionPhrasal
+	}
+	return SectionOther
+}
+
+// IsPhrase reports whether entries of this kind are multi-word expressions
+// better studied as phrases than as single words.
+func (k SectionKind) IsPhrase() bool {
+	return k == SectionCompound || k == SectionPhrasal
+}
+
 type ParsedEntry struct {
 	FromWord    FromWord `json:"from_word"`
 	ToWords     []ToWord `json:"to_word"`
-	Context     string   `json:"context"`
+	Context     string   `json:"context"` // sense of the source word, e.g. "animal"
+	Labels      Labels   `json:"labels"`  // usage and region labels of the source word
 	FromExample string   `json:"from_example"`
 	ToExample   []string `json:"to_example"`
 }
@@ -47,6 +120,7 @@ type ParsedEntry struct {
 type FromWord struct {
 	Source  string
 	Grammar string
+	Tags    GrammarTags `json:"tags"`
 }
 
 func (fw FromWord) String() string {
@@ -57,8 +131,7 @@ func (fw FromWord) String() string {
 }
 
 func (fw FromWord) ColorString() string {
-	sourceStyle := lipgloss.NewStyle().
-		Foreground(lipgloss.Color("#ffffff"))
+	sourceStyle := genderStyle(fw.Tags.Gender)
 	grammarStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#555555")).Italic(true)
 	return fmt.Sprintf("%s %s",
@@ -69,45 +142,47 @@ func (fw FromWord) ColorString() string {
 
 type ToWord struct {
 	Meaning string
-	Notes   string
+	Notes   string // the dsense note distinguishing this translation
 	Grammar string
+	Tags    GrammarTags `json:"tags"`
+	Labels  Labels      `json:"labels"`
 }
 
 func (tw ToWord) String() string {
+	s := fmt.Sprintf("%s (%s)", tw.Meaning, tw.Grammar)
 	if len(tw.Notes) > 0 {
-		return fmt.Sprintf("%s (%s) (%s)",
-			tw.Meaning,
-			tw.Grammar,
-			tw.Notes,
-		)
+		s += fmt.Sprintf(" (%s)", tw.Notes)
 	}
-	return fmt.Sprintf("%s (%s)",
-		tw.Meaning,
-		tw.Grammar,
-	)
+	if labels := tw.Labels.String(); labels != "" {
+		s += fmt.Sprintf(" [%s]", labels)
+	}
+	return s
 }
 
 func (tw ToWord) ColorString() string {
-	meaningStyle := lipgloss.NewStyle().
+	meaningStyle := genderStyle(tw.Tags.Gender)
+	notesStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#ffffff"))
 	grammarStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color("#555555")).Italic(true)
-	if len(tw.Notes) > 0 {
-		return fmt.Sprintf("%s %s (%s)",
-			meaningStyle.Render(tw.Meaning),
-			grammarStyle.Render(tw.Grammar),
-			meaningStyle.Render(tw.Notes),
-		)
-	}
-	return fmt.Sprintf("%s %s",
+	s := fmt.Sprintf("%s %s",
 		meaningStyle.Render(tw.Meaning),
 		grammarStyle.Render(tw.Grammar),
 	)
+	if len(tw.Notes) > 0 {
+		s += fmt.Sprintf(" (%s)", notesStyle.Render(tw.Notes))
+	}
+	if labels := tw.Labels.String(); labels != "" {
+		s += " " + labelStyle.Render("["+labels+"]")
+	}
+	return s
 }
 
+var labelStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#ffb964")).Italic(true)
+
 // Fetches available dictionaries with optional language filtering.
-func getAvailableDicts(langFilter string) (map[string]map[string]string, error) {
-	resp, err := http.Get(WR_URL)
+func getAvailableDicts(client *http.Client, baseURL, langFilter string) (map[string]map[string]string, error) {
+	resp, err := client.Get(baseURL)
 	if err != nil {
 		return nil, err
 	}
@@ -150,10 +225,12 @@ func getAvailableDicts(langFilter string) (map[string]map[string]string, error)
 	return dicts, nil
 }
 
-// Initializes a WordReference object with validation.
-func NewWordReference(fromLang, toLang string) (*WordReference, error) {
+// Initializes a WordReference object with validation. Requests go through
+// transport (http.DefaultTransport if nil).
+func NewWordReference(fromLang, toLang string, transport http.RoundTripper) (*WordReference, error) {
 	dictCode := strings.ToLower(fromLang + toLang)
-	availableDicts, err := getAvailableDicts("")
+	baseURL, client := wordReferenceURL(), newHTTPClient(transport)
+	availableDicts, err := getAvailableDicts(client, baseURL, "")
 	if err != nil {
 		return nil, err
 	}
@@ -167,19 +244,40 @@ func NewWordReference(fromLang, toLang string) (*WordReference, error) {
 		FromLang:  availableDicts[dictCode]["from"],
 		ToLang:    availableDicts[dictCode]["to"],
 		UserAgent: "GoHttpClient",
+		baseURL:   baseURL,
+		client:    client,
+		available: availableDicts,
+	}, nil
+}
+
+// Reversed returns a WordReference for the opposite direction of the same
+// language pair, e.g. esen for enes.
+func (wr *WordReference) Reversed() (*WordReference, error) {
+	code := wr.DictCode[2:] + wr.DictCode[:2]
+	langs, ok := wr.available[code]
+	if !ok {
+		return nil, fmt.Errorf("%s is not available as a translation dictionary", code)
+	}
+	return &WordReference{
+		DictCode:  code,
+		FromLang:  langs["from"],
+		ToLang:    langs["to"],
+		UserAgent: wr.UserAgent,
+		baseURL:   wr.baseURL,
+		client:    wr.client,
+		available: wr.available,
 	}, nil
 }
 
 func (wr *WordReference) Translate(word string) (*Translation, error) {
-	url := fmt.Sprintf(TRANSLATION_URL, wr.DictCode, word)
+	url := wr.baseURL + wr.DictCode + "/" + word
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("User-Agent", wr.UserAgent)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := wr.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -189,10 +287,18 @@ func (wr *WordReference) Translate(word string) (*Translation, error) {
 	if err != nil {
 		return nil, err
 	}
+	return wr.parseTranslation(doc, word, url)
+}
 
+// Parses a WordReference translation page for word, fetched from url.
+func (wr *WordReference) parseTranslation(doc *goquery.Document, word, url string) (*Translation, error) {
 	// Check if the word is not found
 	if noEntry := doc.Find("p#noEntryFound").Text(); noEntry != "" {
-		return nil, errors.New(noEntry)
+		return nil, &NoEntryError{
+			Word:        word,
+			Message:     noEntry,
+			Suggestions: parseSuggestions(doc, wr.baseURL, wr.DictCode, word),
+		}
 	}
 
 	translation := &Translation{
@@ -243,27 +349,74 @@ func (wr *WordReference) Translate(word string) (*Translation, error) {
 		if section == nil {
 			newSection := TranslationSection{
 				Title:   sectionTitle,
+				Kind:    sectionKindFromTitle(sectionTitle),
 				Entries: []ParsedEntry{},
 			}
 			translation.Translations = append(translation.Translations, newSection)
 			section = &translation.Translations[len(translation.Translations)-1]
 		}
 
-		section.Entries = entries
+		// Pages can split a section over several tables with the same title.
+		section.Entries = append(section.Entries, entries...)
 	})
 
 	return translation, nil
 }
 
+// NoEntryError is returned by Translate when WordReference has no entry for
+// the word. Suggestions holds the alternatives the page links to.
+type NoEntryError struct {
+	Word        string
+	Message     string
+	Suggestions []string
+}
+
+func (e *NoEntryError) Error() string {
+	return e.Message
+}
+
+// Parses the suggestion list of a no-entry page, keeping the links to other
+// entries in the same dictionary. The #noEntrySuggestions container comes from
+// the hand-written enes_dgo.html fixture and has not been checked against a
+// live no-entry page; if suggestions stop appearing, check it first.
+func parseSuggestions(doc *goquery.Document, baseURL, dictCode, word string) []string {
+	prefix := "/" + dictCode + "/"
+	seen := map[string]bool{strings.ToLower(word): true}
+	var suggestions []string
+	doc.Find("#noEntrySuggestions a[href]").Each(func(_ int, a *goquery.Selection) {
+		href := a.AttrOr("href", "")
+		href = strings.TrimPrefix(href, strings.TrimSuffix(baseURL, "/"))
+		href = strings.TrimPrefix(href, strings.TrimSuffix(WR_URL, "/"))
+		if !strings.HasPrefix(href, prefix) {
+			return
+		}
+		text := strings.TrimSpace(a.Text())
+		if text == "" || seen[strings.ToLower(text)] {
+			return
+		}
+		seen[strings.ToLower(text)] = true
+		suggestions = append(suggestions, text)
+	})
+	return suggestions
+}
+
 // Parses the source word from an entry.
 func parseFromWord(entry []*goquery.Selection) FromWord {
 	source := strings.TrimSpace(entry[0].Find("td.FrWrd strong").Text())
 	source = strings.ReplaceAll(source, "⇒", "")
 	var grammar string
 	if em := entry[0].Find("td.FrWrd em.POS2"); em.Length() > 0 {
-		grammar = strings.TrimSpace(em.Text())
+		grammar = posText(em)
 	}
-	return FromWord{source, grammar}
+	return FromWord{source, grammar, parseGrammar(grammar)}
+}
+
+// Returns the abbreviation of a grammar tag without the explanation shown in
+// its tooltip, e.g. "nm" from <em class="tooltip POS2">nm<span>...</span></em>.
+func posText(em *goquery.Selection) string {
+	em = em.First().Clone()
+	em.Find("span").Remove()
+	return strings.TrimSpace(em.Text())
 }
 
 // Parses the target words from an entry.
@@ -273,33 +426,43 @@ func parseToWord(entry []*goquery.Selection) []ToWord {
 		if td := tr.Find("td.ToWrd"); td.Length() > 0 {
 			var grammar, notes string
 			if em := td.Find("em.POS2"); em.Length() > 0 {
-				grammar = strings.TrimSpace(em.Text())
+				grammar = posText(em)
 				em.Remove()
 			}
 			meaning := strings.TrimSpace(td.Text())
 			meaning = strings.ReplaceAll(meaning, "⇒", "")
+			meaning, labels := trimLabels(strings.TrimSpace(meaning))
 
-			if span := tr.Find("span.dsense i"); span.Length() > 0 {
-				notes = strings.TrimSpace(span.Text())
+			if span := tr.Find("span.dsense"); span.Length() > 0 {
+				var dsense Labels
+				notes, dsense = splitLabels(strings.TrimSpace(span.Text()))
+				labels.Usage = append(labels.Usage, dsense.Usage...)
+				labels.Regions = append(labels.Regions, dsense.Regions...)
 			}
 			out = append(out, ToWord{
 				meaning,
 				notes,
 				grammar,
+				parseGrammar(grammar),
+				labels,
 			})
 		}
 	}
 	return out
 }
 
-// Parses the context from an entry.
-func parseContext(entry []*goquery.Selection) string {
-	context := entry[0].Find("td:nth-child(2)").Text()
-	re := regexp.MustCompile(`$begin:math:text$(.*?)$end:math:text$`)
-	if matches := re.FindStringSubmatch(context); len(matches) > 1 {
-		return matches[1]
+// Parses the sense context of the source word, e.g. "(animal)", and any
+// usage or region labels next to it, from the middle cell of an entry's
+// first row. The dsense note in the same cell belongs to the first
+// translation and is left to parseToWord.
+func parseContext(entry []*goquery.Selection) (string, Labels) {
+	td := entry[0].Find("td:nth-child(2)").First().Clone()
+	td.Find("span.dsense").Remove()
+	text := strings.TrimSpace(td.Text())
+	if text == "" {
+		return "", Labels{}
 	}
-	return ""
+	return splitLabels(text)
 }
 
 // Parses the example from the source language.
@@ -328,10 +491,12 @@ func parseToExample(entry []*goquery.Selection) []string {
 
 // Parses a full entry into a structured format.
 func parseEntry(entry []*goquery.Selection) ParsedEntry {
+	context, labels := parseContext(entry)
 	return ParsedEntry{
 		FromWord:    parseFromWord(entry),
 		ToWords:     parseToWord(entry),
-		Context:     parseContext(entry),
+		Context:     context,
+		Labels:      labels,
 		FromExample: parseFromExample(entry),
 		ToExample:   parseToExample(entry),
 	}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	updateGolden    = flag.Bool("update", false, "rewrite golden files from the current parser output")
+	refreshFixtures = flag.Bool("refresh", false, "download fresh HTML fixtures from wordreference.com")
+)
+
+// Pages under testdata/wordref, named <dict>_<word>.html. The checked-in
+// pages were written by hand to imitate WordReference's markup; they are not
+// captured pages, and the no-entry page's #noEntrySuggestions list in
+// particular has not been checked against the live site. Run
+//
+//	go test -run TestParseTranslation -refresh -update
+//
+// to replace them with downloaded pages and regenerate the golden JSON, then
+// review the diff: a parser that only matched the imitation shows up there.
+var parserFixtures = []struct {
+	dict, word string
+}{
+	{"enes", "dog"},  // nouns with gender, tooltips, dsense, compound forms
+	{"enes", "run"},  // verbs, pronominal translations, phrasal verbs
+	{"enes", "car"},  // region labels, a section split over two tables
+	{"esen", "casa"}, // reverse dictionary, locutions
+	{"enes", "dgo"},  // no entry, with suggestions
+}
+
+var fixtureLangs = map[string]string{"en": "English", "es": "Spanish"}
+
+// goldenResult is what a fixture's golden file records: the parsed
+// translation, or the no-entry error for pages without one.
+type goldenResult struct {
+	Translation *Translation  `json:"translation,omitempty"`
+	NoEntry     *NoEntryError `json:"no_entry,omitempty"`
+}
+
+func TestParseTranslation(t *testing.T) {
+	for _, fx := range parserFixtures {
+		name := fx.dict + "_" + fx.word
+		t.Run(name, func(t *testing.T) {
+			htmlPath := filepath.Join("testdata", "wordref", name+".html")
+			goldenPath := filepath.Join("testdata", "wordref", name+".golden.json")
+			url := fmt.Sprintf(TRANSLATION_URL, fx.dict, fx.word)
+
+			if *refreshFixtures {
+				if err := downloadFixture(url, htmlPath); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			f, err := os.Open(htmlPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			doc, err := goquery.NewDocumentFromReader(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			wr := &WordReference{
+				DictCode: fx.dict,
+				FromLang: fixtureLangs[fx.dict[:2]],
+				ToLang:   fixtureLangs[fx.dict[2:]],
+			}
+			var result goldenResult
+			result.Translation, err = wr.parseTranslation(doc, fx.word, url)
+			if err != nil && !errors.As(err, &result.NoEntry) {
+				t.Fatal(err)
+			}
+
+			got, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			if *updateGolden {
+				if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("%s (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("parsed %s differs from %s (run with -update to accept):\n%s", htmlPath, goldenPath, lineDiff(string(want), string(got)))
+			}
+		})
+	}
+}
+
+func downloadFixture(url, path string) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "GoHttpClient")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, body, 0o644)
+}
+
+// Reports the first differing line of two texts with a little context.
+func lineDiff(want, got string) string {
+	wl, gl := bytes.Split([]byte(want), []byte("\n")), bytes.Split([]byte(got), []byte("\n"))
+	for i := 0; i < len(wl) || i < len(gl); i++ {
+		var w, g []byte
+		if i < len(wl) {
+			w = wl[i]
+		}
+		if i < len(gl) {
+			g = gl[i]
+		}
+		if !bytes.Equal(w, g) {
+			return fmt.Sprintf("line %d:\n- %s\n+ %s", i+1, w, g)
+		}
+	}
+	return ""
+}